@@ -0,0 +1,620 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/DelineaXPM/tss-sdk-go/v3/gitcredential"
+	"github.com/DelineaXPM/tss-sdk-go/v3/kubesecret"
+	"github.com/DelineaXPM/tss-sdk-go/v3/output"
+	"github.com/DelineaXPM/tss-sdk-go/v3/render"
+	"github.com/DelineaXPM/tss-sdk-go/v3/secretenv"
+	"github.com/DelineaXPM/tss-sdk-go/v3/server"
+)
+
+// multiFlag is a flag that may be given more than once
+type multiFlag []string
+
+func (m *multiFlag) String() string {
+	return strings.Join(*m, ",")
+}
+
+func (m *multiFlag) Set(value string) error {
+	*m = append(*m, value)
+	return nil
+}
+
+// keyValues splits each "key=value" in the flag into a map; form describes
+// the expected form in errors, such as "slug=value"
+func (m multiFlag) keyValues(form string) (map[string]string, error) {
+	values := make(map[string]string, len(m))
+	for _, pair := range m {
+		i := strings.Index(pair, "=")
+		if i < 1 {
+			return nil, usageError{fmt.Sprintf("'%s' is not of the form %s", pair, form)}
+		}
+		values[pair[:i]] = pair[i+1:]
+	}
+	return values, nil
+}
+
+func (c *cli) flags(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	return flags
+}
+
+// parseFlags parses the flags of a command, expecting between min and max
+// positional arguments; a negative max allows any number
+func parseFlags(flags *flag.FlagSet, args []string, min, max int) error {
+	if err := flags.Parse(args); err != nil {
+		return usageError{err.Error()}
+	}
+	if flags.NArg() < min || (max >= 0 && flags.NArg() > max) {
+		return usageError{"wrong number of arguments"}
+	}
+	return nil
+}
+
+func parseID(value string) (int, error) {
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, usageError{fmt.Sprintf("'%s' is not a numeric id", value)}
+	}
+	return id, nil
+}
+
+func (c *cli) printJSON(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(c.stdout, string(data))
+	return err
+}
+
+// outputFlags holds the flags that control how secrets are printed
+type outputFlags struct {
+	format, prefix string
+	reveal         bool
+}
+
+func (o *outputFlags) register(flags *flag.FlagSet, defaultFormat output.Format) {
+	flags.StringVar(&o.format, "format", string(defaultFormat), "the output `format`: json, yaml, dotenv, shell or table")
+	flags.StringVar(&o.prefix, "prefix", "", "prefix the variable names of the dotenv and shell formats with `prefix`")
+	flags.BoolVar(&o.reveal, "reveal", false, "print the values of password and file fields instead of masking them")
+}
+
+func (o outputFlags) parse() (output.Format, output.Options, error) {
+	format, err := output.ParseFormat(o.format)
+	if err != nil {
+		return "", output.Options{}, usageError{err.Error()}
+	}
+	return format, output.Options{Reveal: o.reveal, Prefix: o.prefix}, nil
+}
+
+// lookup gets the secret identified either by the path flag or by the first
+// positional argument
+func (c *cli) lookup(tss *server.Server, path string, args []string) (*server.Secret, []string, error) {
+	if path != "" {
+		secret, err := tss.SecretByPath(path)
+		return secret, args, err
+	}
+	if len(args) == 0 {
+		return nil, nil, usageError{"either -path or a secret id is required"}
+	}
+	id, err := parseID(args[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	secret, err := tss.Secret(id)
+	return secret, args[1:], err
+}
+
+func (c *cli) get(args []string) error {
+	var outputs outputFlags
+	flags := c.flags("get")
+	path := flags.String("path", "", "the folder `path` and name of the secret")
+	outputs.register(flags, output.JSON)
+	if err := parseFlags(flags, args, 0, 1); err != nil {
+		return err
+	}
+	format, options, err := outputs.parse()
+	if err != nil {
+		return err
+	}
+	tss, err := c.connection.connect()
+	if err != nil {
+		return err
+	}
+
+	secret, _, err := c.lookup(tss, *path, flags.Args())
+	if err != nil {
+		return err
+	}
+	return output.WriteSecret(c.stdout, format, secret, options)
+}
+
+func (c *cli) getField(args []string) error {
+	flags := c.flags("get-field")
+	path := flags.String("path", "", "the folder `path` and name of the secret")
+	if err := parseFlags(flags, args, 1, 2); err != nil {
+		return err
+	}
+	tss, err := c.connection.connect()
+	if err != nil {
+		return err
+	}
+
+	secret, rest, err := c.lookup(tss, *path, flags.Args())
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		return usageError{"a field name is required"}
+	}
+	value, found := secret.Field(rest[0])
+	if !found {
+		return fmt.Errorf("the secret '%s' has no field '%s'", secret.Name, rest[0])
+	}
+	_, err = fmt.Fprintln(c.stdout, value)
+	return err
+}
+
+func (c *cli) search(args []string) error {
+	var outputs outputFlags
+	flags := c.flags("search")
+	field := flags.String("field", "", "search only the field with the given `slug`")
+	inactive := flags.Bool("inactive", false, "include deleted secrets")
+	outputs.register(flags, output.Table)
+	if err := parseFlags(flags, args, 1, 1); err != nil {
+		return err
+	}
+	format, options, err := outputs.parse()
+	if err != nil {
+		return err
+	}
+	tss, err := c.connection.connect()
+	if err != nil {
+		return err
+	}
+
+	secrets, err := tss.SecretsWithOptions(flags.Arg(0), *field, server.LookupOptions{IncludeInactive: *inactive})
+	if err != nil {
+		return err
+	}
+	return output.WriteSecrets(c.stdout, format, secrets, options)
+}
+
+func (c *cli) create(args []string) error {
+	var fields, files, generate multiFlag
+	flags := c.flags("create")
+	templateID := flags.Int("template", 0, "the `id` of the secret template")
+	siteID := flags.Int("site", 1, "the `id` of the distributed engine site")
+	folderID := flags.Int("folder", -1, "the `id` of the folder")
+	name := flags.String("name", "", "the `name` of the secret")
+	sshKeys := flags.Bool("ssh-keys", false, "generate an SSH key pair")
+	sshPassphrase := flags.Bool("ssh-passphrase", false, "generate an SSH key pair and a private key passphrase")
+	flags.Var(&fields, "field", "set a field, as `slug=value`")
+	flags.Var(&files, "file", "set a file field to the contents of a file, as `slug=path`")
+	flags.Var(&generate, "generate", "generate the password field with the given `slug`")
+	if err := parseFlags(flags, args, 0, 0); err != nil {
+		return err
+	}
+	if *templateID == 0 {
+		return usageError{"-template is required"}
+	}
+	fieldValues, err := fields.keyValues("slug=value")
+	if err != nil {
+		return err
+	}
+	filePaths, err := files.keyValues("slug=path")
+	if err != nil {
+		return err
+	}
+	tss, err := c.connection.connect()
+	if err != nil {
+		return err
+	}
+
+	template, err := tss.SecretTemplate(*templateID)
+	if err != nil {
+		return err
+	}
+	builder := server.NewSecretBuilder(template).Name(*name).Site(*siteID).Folder(*folderID)
+	for slug, value := range fieldValues {
+		builder.Field(slug, value)
+	}
+	for slug, path := range filePaths {
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		builder.File(slug, filepath.Base(path), string(contents))
+	}
+	for _, slug := range generate {
+		builder.GeneratePassword(slug)
+	}
+	if *sshKeys || *sshPassphrase {
+		builder.SshKeys(*sshPassphrase)
+	}
+
+	secret, err := builder.Create(*tss)
+	if err != nil {
+		return err
+	}
+	return c.printJSON(secret)
+}
+
+func (c *cli) update(args []string) error {
+	var fields, files multiFlag
+	flags := c.flags("update")
+	flags.Var(&fields, "field", "set a field, as `slug=value`")
+	flags.Var(&files, "file", "set a file field to the contents of a file, as `slug=path`")
+	if err := parseFlags(flags, args, 1, 1); err != nil {
+		return err
+	}
+	id, err := parseID(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	fieldValues, err := fields.keyValues("slug=value")
+	if err != nil {
+		return err
+	}
+	filePaths, err := files.keyValues("slug=path")
+	if err != nil {
+		return err
+	}
+	if len(fieldValues) == 0 && len(filePaths) == 0 {
+		return usageError{"at least one -field or -file is required"}
+	}
+	tss, err := c.connection.connect()
+	if err != nil {
+		return err
+	}
+
+	// fields alone can be patched; files require the whole secret to be written
+	if len(filePaths) == 0 {
+		return tss.UpdateFields(id, fieldValues)
+	}
+	secret, err := tss.Secret(id)
+	if err != nil {
+		return err
+	}
+	for i, field := range secret.Fields {
+		if value, found := fieldValues[field.Slug]; found {
+			secret.Fields[i].ItemValue = value
+			delete(fieldValues, field.Slug)
+		}
+		if path, found := filePaths[field.Slug]; found {
+			contents, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			secret.Fields[i].ItemValue = string(contents)
+			secret.Fields[i].Filename = filepath.Base(path)
+			delete(filePaths, field.Slug)
+		}
+	}
+	for slug := range fieldValues {
+		return fmt.Errorf("the secret '%s' has no field '%s'", secret.Name, slug)
+	}
+	for slug := range filePaths {
+		return fmt.Errorf("the secret '%s' has no field '%s'", secret.Name, slug)
+	}
+	_, err = tss.UpdateSecret(*secret)
+	return err
+}
+
+func (c *cli) delete(args []string) error {
+	flags := c.flags("delete")
+	if err := parseFlags(flags, args, 1, 1); err != nil {
+		return err
+	}
+	id, err := parseID(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	tss, err := c.connection.connect()
+	if err != nil {
+		return err
+	}
+	return tss.DeleteSecret(id)
+}
+
+func (c *cli) template(args []string) error {
+	if len(args) == 0 || args[0] != "show" {
+		return usageError{"unknown template command"}
+	}
+	flags := c.flags("template show")
+	name := flags.String("name", "", "the `name` of the secret template")
+	if err := parseFlags(flags, args[1:], 0, 1); err != nil {
+		return err
+	}
+	tss, err := c.connection.connect()
+	if err != nil {
+		return err
+	}
+
+	var template *server.SecretTemplate
+	switch {
+	case *name != "":
+		var found bool
+		if template, found, err = tss.SecretTemplateByName(*name); err == nil && !found {
+			err = fmt.Errorf("there is no secret template named '%s'", *name)
+		}
+	case flags.NArg() == 1:
+		var id int
+		if id, err = parseID(flags.Arg(0)); err == nil {
+			template, err = tss.SecretTemplate(id)
+		}
+	default:
+		err = usageError{"either -name or a template id is required"}
+	}
+	if err != nil {
+		return err
+	}
+	return c.printJSON(template)
+}
+
+func (c *cli) generatePassword(args []string) error {
+	flags := c.flags("generate-password")
+	templateID := flags.Int("template", 0, "the `id` of the secret template")
+	slug := flags.String("field", "password", "the `slug` of the password field")
+	if err := parseFlags(flags, args, 0, 0); err != nil {
+		return err
+	}
+	if *templateID == 0 {
+		return usageError{"-template is required"}
+	}
+	tss, err := c.connection.connect()
+	if err != nil {
+		return err
+	}
+
+	template, err := tss.SecretTemplate(*templateID)
+	if err != nil {
+		return err
+	}
+	if _, found := template.GetField(*slug); !found {
+		return fmt.Errorf("the secret template '%s' has no field '%s'", template.Name, *slug)
+	}
+	password, err := tss.GeneratePassword(*slug, template)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(c.stdout, password)
+	return err
+}
+
+func (c *cli) downloadFile(args []string) error {
+	flags := c.flags("download-file")
+	outputPath := flags.String("o", "", "write the file to `path` instead of standard output")
+	if err := parseFlags(flags, args, 2, 2); err != nil {
+		return err
+	}
+	id, err := parseID(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	tss, err := c.connection.connect()
+	if err != nil {
+		return err
+	}
+
+	secret, err := tss.Secret(id)
+	if err != nil {
+		return err
+	}
+	for _, field := range secret.Fields {
+		if field.Slug != flags.Arg(1) && field.FieldName != flags.Arg(1) {
+			continue
+		}
+		if !field.IsFile {
+			return fmt.Errorf("the field '%s' of the secret '%s' is not a file field", flags.Arg(1), secret.Name)
+		}
+		if *outputPath == "" {
+			_, err = c.stdout.Write([]byte(field.ItemValue))
+			return err
+		}
+		return ioutil.WriteFile(*outputPath, []byte(field.ItemValue), 0600)
+	}
+	return fmt.Errorf("the secret '%s' has no field '%s'", secret.Name, flags.Arg(1))
+}
+
+func (c *cli) exec(args []string) error {
+	var maps multiFlag
+	flags := c.flags("exec")
+	flags.Var(&maps, "map", "set the environment variable `NAME` to a secret field, as NAME=tss://id/<id>#<field> or NAME=tss://path/<path>#<field>")
+	if err := parseFlags(flags, args, 1, -1); err != nil {
+		return err
+	}
+	mappings := make([]secretenv.Mapping, len(maps))
+	for i, value := range maps {
+		mapping, err := secretenv.ParseMapping(value)
+		if err != nil {
+			return usageError{err.Error()}
+		}
+		mappings[i] = mapping
+	}
+	tss, err := c.connection.connect()
+	if err != nil {
+		return err
+	}
+
+	code, err := secretenv.Run(tss, mappings, flags.Arg(0), flags.Args()[1:]...)
+	if err != nil {
+		return err
+	}
+	if code != 0 {
+		return exitStatus(code)
+	}
+	return nil
+}
+
+func (c *cli) render(args []string) error {
+	flags := c.flags("render")
+	outputPath := flags.String("o", "", "write the rendering to `path` instead of standard output")
+	watch := flags.Duration("watch", 0, "re-render every `interval` and run the command when the rendering changes; requires -o")
+	if err := parseFlags(flags, args, 1, -1); err != nil {
+		return err
+	}
+	if *watch < 0 {
+		return usageError{"-watch requires a positive interval"}
+	}
+	if *watch != 0 && *outputPath == "" {
+		return usageError{"-watch requires -o"}
+	}
+	tss, err := c.connection.connect()
+	if err != nil {
+		return err
+	}
+	template, err := render.ParseFile(tss, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	// the command, if any, is run after each rendering
+	var onChange func() error
+	command := flags.Args()[1:]
+	if len(command) > 0 && command[0] == "--" {
+		command = command[1:]
+	}
+	if len(command) > 0 {
+		onChange = func() error {
+			cmd := exec.Command(command[0], command[1:]...)
+			cmd.Stdout, cmd.Stderr = c.stdout, c.stderr
+			return cmd.Run()
+		}
+	}
+
+	switch {
+	case *watch != 0:
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+		defer signal.Stop(signals)
+		stop := make(chan struct{})
+		go func() {
+			<-signals
+			close(stop)
+		}()
+		// failed refreshes are reported, since the log is discarded
+		// without -debug
+		return template.Watch(*outputPath, *watch, stop, onChange, func(err error) {
+			fmt.Fprintf(c.stderr, "tss render: %s\n", err)
+		})
+	case *outputPath != "":
+		err = template.WriteFile(*outputPath)
+	default:
+		err = template.Execute(c.stdout)
+	}
+	if err == nil && onChange != nil {
+		err = onChange()
+	}
+	return err
+}
+
+func (c *cli) k8sSecret(args []string) error {
+	var keys, labels, annotations multiFlag
+	var options kubesecret.Options
+	flags := c.flags("k8s-secret")
+	path := flags.String("path", "", "the folder `path` and name of the secret")
+	folderID := flags.Int("folder", 0, "convert the secrets in the folder with the given `id` instead of a single secret")
+	recursive := flags.Bool("recursive", false, "with -folder, include the secrets in subfolders")
+	outputPath := flags.String("o", "", "write the manifests to `path` instead of standard output")
+	flags.StringVar(&options.Namespace, "namespace", "", "the `namespace` of the Kubernetes Secrets")
+	flags.StringVar(&options.Name, "name", "", "the `name` of the Kubernetes Secret, by default derived from the name of the secret")
+	flags.StringVar(&options.Type, "type", kubesecret.DefaultType, "the `type` of the Kubernetes Secrets")
+	flags.Var(&keys, "key", "store the field with the given slug under another key, as `slug=key`")
+	flags.BoolVar(&options.OnlyMapped, "only-mapped", false, "leave out the fields that are not mapped by -key")
+	flags.Var(&labels, "label", "add a label, as `key=value`")
+	flags.Var(&annotations, "annotation", "add an annotation, as `key=value`")
+	if err := parseFlags(flags, args, 0, 1); err != nil {
+		return err
+	}
+	var err error
+	if options.Keys, err = keys.keyValues("slug=key"); err != nil {
+		return err
+	}
+	if options.Labels, err = labels.keyValues("key=value"); err != nil {
+		return err
+	}
+	if options.Annotations, err = annotations.keyValues("key=value"); err != nil {
+		return err
+	}
+	if *folderID != 0 && (*path != "" || flags.NArg() > 0) {
+		return usageError{"-folder cannot be combined with -path or a secret id"}
+	}
+	tss, err := c.connection.connect()
+	if err != nil {
+		return err
+	}
+
+	var manifests []*kubesecret.Manifest
+	if *folderID != 0 {
+		if manifests, err = kubesecret.FromFolder(tss, *folderID, *recursive, options); err != nil {
+			return err
+		}
+	} else {
+		secret, _, err := c.lookup(tss, *path, flags.Args())
+		if err != nil {
+			return err
+		}
+		manifest, err := kubesecret.FromSecret(secret, options)
+		if err != nil {
+			return err
+		}
+		manifests = append(manifests, manifest)
+	}
+
+	if *outputPath == "" {
+		return kubesecret.WriteYAML(c.stdout, manifests...)
+	}
+	file, err := os.OpenFile(*outputPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if err = kubesecret.WriteYAML(file, manifests...); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func (c *cli) gitCredential(args []string) error {
+	var helper gitcredential.Helper
+	flags := c.flags("git-credential")
+	flags.IntVar(&helper.TemplateID, "template", 0, "the `id` of the secret template of stored credentials")
+	flags.IntVar(&helper.FolderID, "folder", -1, "the `id` of the folder of stored credentials")
+	flags.IntVar(&helper.SiteID, "site", 1, "the `id` of the distributed engine site of stored credentials")
+	flags.StringVar(&helper.URLField, "url-field", gitcredential.DefaultURLField, "the `slug` of the field that holds the URL of the remote")
+	flags.StringVar(&helper.UsernameField, "username-field", gitcredential.DefaultUsernameField, "the `slug` of the field that holds the username")
+	flags.StringVar(&helper.PasswordField, "password-field", gitcredential.DefaultPasswordField, "the `slug` of the field that holds the password or token")
+	flags.BoolVar(&helper.DeleteOnErase, "delete-on-erase", false, "delete the secret holding a credential that git erases, instead of keeping it")
+	if err := parseFlags(flags, args, 1, 1); err != nil {
+		return err
+	}
+	switch flags.Arg(0) {
+	case gitcredential.ActionGet, gitcredential.ActionStore, gitcredential.ActionErase:
+	default:
+		// git expects helpers to ignore actions they do not support
+		return nil
+	}
+	tss, err := c.connection.connect()
+	if err != nil {
+		return err
+	}
+
+	helper.Secrets = tss
+	return helper.Run(flags.Arg(0), c.stdin, c.stdout)
+}
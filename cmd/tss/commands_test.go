@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/DelineaXPM/tss-sdk-go/v3/server"
+	"github.com/DelineaXPM/tss-sdk-go/v3/tsstest"
+)
+
+// commandTest runs tss against a fake Secret Server, which holds the Password
+// secret /Prod/DB and the SSH key secret /Prod/Host
+type commandTest struct {
+	t        *testing.T
+	fake     *tsstest.Server
+	password server.SecretTemplate
+	db, host server.Secret
+	// config is an empty configuration file, so that the profiles of the user
+	// do not apply
+	config string
+	// environment holds the values of the variables that were replaced
+	environment map[string]string
+}
+
+func newCommandTest(t *testing.T) *commandTest {
+	test := &commandTest{t: t, fake: tsstest.NewServer()}
+	test.password = test.fake.AddTemplate(tsstest.PasswordTemplate())
+	ssh := test.fake.AddTemplate(tsstest.SshKeyTemplate())
+	test.fake.AddFolder(7, "/Prod")
+	var err error
+	if test.db, err = test.fake.AddSecret(server.Secret{Name: "DB", SecretTemplateID: test.password.ID, FolderID: 7,
+		Fields: []server.SecretField{{Slug: "username", ItemValue: "app"}, {Slug: "password", ItemValue: "s3cret"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if test.host, err = test.fake.AddSecret(server.Secret{Name: "Host", SecretTemplateID: ssh.ID, FolderID: 7,
+		Fields: []server.SecretField{{Slug: "username", ItemValue: "root"},
+			{Slug: "private-key", ItemValue: "PRIVATE KEY", Filename: "id_rsa"}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := ioutil.TempFile("", "tss")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.WriteString("{}")
+	config.Close()
+	test.config = config.Name()
+
+	// the environment, which takes precedence over the configuration file,
+	// points at the fake
+	test.environment = make(map[string]string)
+	for name, value := range map[string]string{
+		server.EnvServerURL: test.fake.URL,
+		server.EnvUsername:  tsstest.Username,
+		server.EnvPassword:  tsstest.Password,
+		server.EnvTenant:    "",
+		server.EnvDomain:    "",
+		server.EnvToken:     "",
+	} {
+		test.environment[name] = os.Getenv(name)
+		os.Setenv(name, value)
+	}
+	return test
+}
+
+func (test *commandTest) Close() {
+	for name, value := range test.environment {
+		os.Setenv(name, value)
+	}
+	os.Remove(test.config)
+	test.fake.Close()
+}
+
+// run runs tss with the given arguments, and returns its exit code and output
+func (test *commandTest) run(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(append([]string{"-config", test.config}, args...), strings.NewReader(""), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+// succeed runs tss and fails the test unless it succeeds, returning its output
+func (test *commandTest) succeed(args ...string) string {
+	code, stdout, stderr := test.run(args...)
+	if code != 0 {
+		test.t.Fatalf("expected %q to succeed, found exit code %d: %s", args, code, stderr)
+	}
+	return stdout
+}
+
+// TestGetCommands tests the get, get-field and search commands
+func TestGetCommands(t *testing.T) {
+	test := newCommandTest(t)
+	defer test.Close()
+	id := strconv.Itoa(test.db.ID)
+
+	var secret server.Secret
+	if err := json.Unmarshal([]byte(test.succeed("get", id)), &secret); err != nil {
+		t.Fatal(err)
+	}
+	if secret.Name != "DB" {
+		t.Errorf("expected the secret DB, found %q", secret.Name)
+	}
+	if password, _ := secret.Field("password"); password == "s3cret" {
+		t.Error("expected the password to be masked without -reveal")
+	}
+	if stdout := test.succeed("get", "-format", "dotenv", "-reveal", "-path", "/Prod/DB"); !strings.Contains(stdout, "PASSWORD=") ||
+		!strings.Contains(stdout, "s3cret") {
+		t.Errorf("expected the revealed password, found %q", stdout)
+	}
+
+	if stdout := test.succeed("get-field", id, "password"); stdout != "s3cret\n" {
+		t.Errorf("expected the password, found %q", stdout)
+	}
+	if stdout := test.succeed("get-field", "-path", "/Prod/DB", "username"); stdout != "app\n" {
+		t.Errorf("expected the username, found %q", stdout)
+	}
+	if code, _, stderr := test.run("get-field", id, "nonexistent"); code != 1 || !strings.Contains(stderr, "no field 'nonexistent'") {
+		t.Errorf("expected an error for a field that does not exist, found exit code %d: %s", code, stderr)
+	}
+	if code, _, _ := test.run("get", "999"); code != 1 {
+		t.Errorf("expected exit code 1 for a secret that does not exist, found %d", code)
+	}
+
+	stdout := test.succeed("search", "-field", "username", "app")
+	if !strings.Contains(stdout, id) || !strings.Contains(stdout, "DB") || strings.Contains(stdout, "Host") {
+		t.Errorf("expected only DB to be found, found %q", stdout)
+	}
+}
+
+// TestWriteCommands tests the create, update and delete commands
+func TestWriteCommands(t *testing.T) {
+	test := newCommandTest(t)
+	defer test.Close()
+
+	var created server.Secret
+	stdout := test.succeed("create", "-template", strconv.Itoa(test.password.ID), "-folder", "7", "-name", "API",
+		"-field", "username=api", "-generate", "password")
+	if err := json.Unmarshal([]byte(stdout), &created); err != nil {
+		t.Fatal(err)
+	}
+	stored, found := test.fake.Secret(created.ID)
+	if !found || stored.Name != "API" || stored.FolderID != 7 {
+		t.Fatalf("expected the secret to be created, found %+v", stored)
+	}
+	if password, _ := stored.Field("password"); password == "" {
+		t.Error("expected a generated password")
+	}
+	if code, _, stderr := test.run("create", "-template", strconv.Itoa(test.password.ID), "-name", "Incomplete"); code != 1 ||
+		!strings.Contains(stderr, "username") {
+		t.Errorf("expected a validation error for the missing fields, found exit code %d: %s", code, stderr)
+	}
+
+	id := strconv.Itoa(created.ID)
+	test.succeed("update", "-field", "password=changed", id)
+	stored, _ = test.fake.Secret(created.ID)
+	if password, _ := stored.Field("password"); password != "changed" {
+		t.Errorf("expected the password to be updated, found %q", password)
+	}
+
+	dir, err := ioutil.TempDir("", "tss")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	keyPath := filepath.Join(dir, "id_ed25519")
+	if err = ioutil.WriteFile(keyPath, []byte("NEW PRIVATE KEY"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	test.succeed("update", "-field", "username=admin", "-file", "private-key="+keyPath, strconv.Itoa(test.host.ID))
+	stored, _ = test.fake.Secret(test.host.ID)
+	if username, _ := stored.Field("username"); username != "admin" {
+		t.Errorf("expected the username to be updated, found %q", username)
+	}
+	if key, _ := stored.Field("private-key"); key != "NEW PRIVATE KEY" {
+		t.Errorf("expected the private key to be replaced, found %q", key)
+	}
+
+	test.succeed("delete", id)
+	if stored, _ = test.fake.Secret(created.ID); stored.Active {
+		t.Error("expected the secret to be deleted")
+	}
+}
+
+// TestTemplateCommands tests the template and generate-password commands
+func TestTemplateCommands(t *testing.T) {
+	test := newCommandTest(t)
+	defer test.Close()
+	id := strconv.Itoa(test.password.ID)
+
+	for _, args := range [][]string{{"template", "show", id}, {"template", "show", "-name", "Password"}} {
+		var template server.SecretTemplate
+		if err := json.Unmarshal([]byte(test.succeed(args...)), &template); err != nil {
+			t.Fatal(err)
+		}
+		if template.ID != test.password.ID || len(template.Fields) != len(test.password.Fields) {
+			t.Errorf("expected the Password template for %q, found %+v", args, template)
+		}
+	}
+	if code, _, _ := test.run("template", "show", "-name", "Nonexistent"); code != 1 {
+		t.Errorf("expected exit code 1 for a template that does not exist, found %d", code)
+	}
+
+	if stdout := test.succeed("generate-password", "-template", id); len(strings.TrimSpace(stdout)) == 0 {
+		t.Error("expected a generated password")
+	}
+	if code, _, stderr := test.run("generate-password", "-template", id, "-field", "username"); code != 1 {
+		t.Errorf("expected an error generating a password for a field that is not a password, found exit code %d: %s", code, stderr)
+	}
+}
+
+// TestDownloadFileCommand tests the download-file command
+func TestDownloadFileCommand(t *testing.T) {
+	test := newCommandTest(t)
+	defer test.Close()
+	id := strconv.Itoa(test.host.ID)
+
+	if stdout := test.succeed("download-file", id, "private-key"); stdout != "PRIVATE KEY" {
+		t.Errorf("expected the private key, found %q", stdout)
+	}
+
+	dir, err := ioutil.TempDir("", "tss")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "id_rsa")
+	test.succeed("download-file", "-o", path, id, "Private Key")
+	if contents, err := ioutil.ReadFile(path); err != nil || string(contents) != "PRIVATE KEY" {
+		t.Errorf("expected the private key to be written, found %q (%v)", contents, err)
+	}
+
+	if code, _, stderr := test.run("download-file", id, "username"); code != 1 || !strings.Contains(stderr, "not a file field") {
+		t.Errorf("expected an error downloading a field that is not a file, found exit code %d: %s", code, stderr)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/DelineaXPM/tss-sdk-go/v3/server"
+)
+
+// connectionFlags holds the command-line flags that configure the connection
+// to Secret Server. There are no flags for the password or an access token,
+// since the arguments of a process are visible to other users; they come from
+// TSS_PASSWORD and TSS_TOKEN, the profile or the configuration file.
+type connectionFlags struct {
+	configFile, profile, serverURL, tenant, tld string
+	username, domain                            string
+}
+
+func (c *connectionFlags) register(flags *flag.FlagSet) {
+	flags.StringVar(&c.configFile, "config", "", "read the configuration from the given JSON `file` instead of the profile file")
+	flags.StringVar(&c.profile, "profile", "", "use the named `profile` from the profile file (env TSS_PROFILE)")
+	flags.StringVar(&c.serverURL, "url", "", "the `URL` of Secret Server or Platform (env TSS_SERVER_URL)")
+	flags.StringVar(&c.tenant, "tenant", "", "the Secret Server Cloud `tenant` (env TSS_TENANT)")
+	flags.StringVar(&c.tld, "tld", "", "the top-level `domain` of Secret Server Cloud (env TSS_TLD)")
+	flags.StringVar(&c.username, "username", "", "the `user` to authenticate as (env TSS_USERNAME)")
+	flags.StringVar(&c.domain, "domain", "", "the `domain` of the user (env TSS_DOMAIN)")
+}
+
+// configuration merges the configuration file, or else the profile file, the
+// environment and the flags, in increasing order of precedence
+func (c connectionFlags) configuration(getenv func(string) string) (*server.Configuration, error) {
+	var config *server.Configuration
+
+	if c.configFile != "" {
+		data, err := ioutil.ReadFile(c.configFile)
+		if err != nil {
+			return nil, err
+		}
+		config = new(server.Configuration)
+		if err = json.Unmarshal(data, config); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", c.configFile, err)
+		}
+	} else {
+		var err error
+		config, err = server.ConfigurationFromEnvFunc(func(name string) string {
+			if name == server.EnvProfile && c.profile != "" {
+				return c.profile
+			}
+			return getenv(name)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	override := func(target *string, flagValue, envName string) {
+		if flagValue != "" {
+			*target = flagValue
+		} else if envValue := getenv(envName); envValue != "" {
+			*target = envValue
+		}
+	}
+	override(&config.ServerURL, c.serverURL, server.EnvServerURL)
+	override(&config.Tenant, c.tenant, server.EnvTenant)
+	override(&config.TLD, c.tld, server.EnvTLD)
+	override(&config.Credentials.Username, c.username, server.EnvUsername)
+	override(&config.Credentials.Password, "", server.EnvPassword)
+	override(&config.Credentials.Domain, c.domain, server.EnvDomain)
+	override(&config.Credentials.Token, "", server.EnvToken)
+
+	// a URL from the flags replaces a tenant from the environment or the file,
+	// and vice versa, since New rejects a configuration with both
+	if c.serverURL != "" && c.tenant == "" {
+		config.Tenant = ""
+	} else if c.tenant != "" && c.serverURL == "" {
+		config.ServerURL = ""
+	}
+	return config, nil
+}
+
+// connect returns a Server configured by the flags, the environment and the
+// configuration file
+func (c connectionFlags) connect() (*server.Server, error) {
+	config, err := c.configuration(os.Getenv)
+	if err != nil {
+		return nil, err
+	}
+	return server.New(*config)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestConnectionConfiguration tests that flags take precedence over the
+// environment, which takes precedence over the configuration file
+func TestConnectionConfiguration(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tss")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	configFile := filepath.Join(dir, "config.json")
+	configJSON := `{"credentials": {"username": "file-user", "password": "file-password"}, "tenant": "file-tenant"}`
+	if err := ioutil.WriteFile(configFile, []byte(configJSON), 0600); err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{"TSS_PASSWORD": "env-password"}
+
+	flags := connectionFlags{configFile: configFile, serverURL: "https://example.com/SecretServer"}
+	config, err := flags.configuration(func(name string) string { return env[name] })
+	if err != nil {
+		t.Fatal("calling connectionFlags.configuration:", err)
+	}
+
+	if config.Credentials.Username != "file-user" {
+		t.Errorf("expected the username from the file, found '%s'", config.Credentials.Username)
+	}
+	if config.Credentials.Password != "env-password" {
+		t.Errorf("expected the password from the environment, found '%s'", config.Credentials.Password)
+	}
+	if config.ServerURL != "https://example.com/SecretServer" || config.Tenant != "" {
+		t.Errorf("expected the URL from the flags to replace the tenant, found '%s' and '%s'", config.ServerURL, config.Tenant)
+	}
+}
+
+// TestRunUsage tests that unknown commands and bad arguments are usage errors
+func TestRunUsage(t *testing.T) {
+	for _, args := range [][]string{
+		{}, {"nonexistent"}, {"get-field"}, {"delete", "not-a-number"},
+		{"-password", "s3cret", "get", "42"}, {"-token", "s3cret", "get", "42"},
+		{"render", "-o", "out", "-watch=-1s", "in.tmpl"},
+	} {
+		if code := run(args, strings.NewReader(""), ioutil.Discard, ioutil.Discard); code != 2 {
+			t.Errorf("expected exit code 2 for %q, found %d", args, code)
+		}
+	}
+}
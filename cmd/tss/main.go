@@ -0,0 +1,138 @@
+// Command tss is a command-line client for Delinea Secret Server, built on the
+// tss-sdk-go server package.
+//
+// Usage:
+//
+//	tss [connection flags] <command> [command flags] [arguments]
+//
+// Run "tss help" for the list of commands.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// usageError is returned by a command when it was invoked incorrectly
+type usageError struct {
+	message string
+}
+
+func (e usageError) Error() string {
+	return e.message
+}
+
+// exitStatus is returned by a command to exit with the given code without
+// printing an error
+type exitStatus int
+
+func (e exitStatus) Error() string {
+	return fmt.Sprintf("exit status %d", int(e))
+}
+
+// command is a subcommand of tss
+type command struct {
+	usage, description string
+	run                func(c *cli, args []string) error
+}
+
+// cli holds the state shared by the commands
+type cli struct {
+	connection connectionFlags
+	stdin      io.Reader
+	stdout     io.Writer
+	stderr     io.Writer
+}
+
+var commands = map[string]command{
+	"get":               {"get [-path path] [-format format] [-reveal] [id]", "print a secret", (*cli).get},
+	"get-field":         {"get-field [-path path] [id] field", "print the value of a field of a secret", (*cli).getField},
+	"search":            {"search [-field field] [-inactive] [-format format] [-reveal] text", "list the secrets that match the search text", (*cli).search},
+	"create":            {"create -template id -site id -folder id -name name [-field slug=value]... [-file slug=path]... [-generate slug]...", "create a secret", (*cli).create},
+	"update":            {"update [-field slug=value]... [-file slug=path]... id", "update fields of a secret", (*cli).update},
+	"delete":            {"delete id", "delete (deactivate) a secret", (*cli).delete},
+	"template":          {"template show [-name name] [id]", "print a secret template", (*cli).template},
+	"generate-password": {"generate-password -template id [-field slug]", "generate a password for a field of a secret template", (*cli).generatePassword},
+	"download-file":     {"download-file [-o file] id field", "write the contents of a file field of a secret", (*cli).downloadFile},
+	"render":            {"render [-o file] [-watch interval] template [-- command [arguments]]", "render a template with secret values", (*cli).render},
+	"k8s-secret":        {"k8s-secret [-path path | -folder id [-recursive]] [-namespace namespace] [-name name] [-type type] [-key slug=key]... [-only-mapped] [-label key=value]... [-annotation key=value]... [-o file] [id]", "print secrets as Kubernetes Secret manifests", (*cli).k8sSecret},
+	"git-credential":    {"git-credential [-template id] [-folder id] [-site id] [-url-field slug] [-username-field slug] [-password-field slug] [-delete-on-erase] get|store|erase", "act as a git credential helper", (*cli).gitCredential},
+	"exec":              {"exec -map NAME=tss://id/<id>#<field>... -- program [arguments]", "run a program with secrets in its environment", (*cli).exec},
+}
+
+// credentialHelperName is the name under which tss runs as a git credential
+// helper, since git runs the helper "tss" as git-credential-tss
+const credentialHelperName = "git-credential-tss"
+
+func main() {
+	args := os.Args[1:]
+	if strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe") == credentialHelperName {
+		args = append([]string{"git-credential"}, args...)
+	}
+	os.Exit(run(args, os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs tss with the given arguments and returns its exit code
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	c := &cli{stdin: stdin, stdout: stdout, stderr: stderr}
+
+	flags := flag.NewFlagSet("tss", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	debug := flags.Bool("debug", false, "log the requests made to the server")
+	c.connection.register(flags)
+	flags.Usage = func() { c.usage(flags) }
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if !*debug {
+		log.SetOutput(ioutil.Discard)
+	}
+	if flags.NArg() == 0 || flags.Arg(0) == "help" {
+		c.usage(flags)
+		return 2
+	}
+
+	cmd, found := commands[flags.Arg(0)]
+	if !found {
+		fmt.Fprintf(stderr, "tss: unknown command '%s'\n", flags.Arg(0))
+		c.usage(flags)
+		return 2
+	}
+	if err := cmd.run(c, flags.Args()[1:]); err != nil {
+		if status, ok := err.(exitStatus); ok {
+			return int(status)
+		}
+		fmt.Fprintf(stderr, "tss %s: %s\n", flags.Arg(0), err)
+		if _, ok := err.(usageError); ok {
+			fmt.Fprintf(stderr, "usage: tss %s\n", cmd.usage)
+			return 2
+		}
+		return 1
+	}
+	return 0
+}
+
+func (c *cli) usage(flags *flag.FlagSet) {
+	fmt.Fprintln(c.stderr, "usage: tss [connection flags] <command> [command flags] [arguments]")
+	fmt.Fprintln(c.stderr, "\ncommands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(c.stderr, "  %-18s %s\n", name, commands[name].description)
+	}
+
+	fmt.Fprintln(c.stderr, "\nconnection flags:")
+	flags.PrintDefaults()
+}
@@ -0,0 +1,281 @@
+// Package gitcredential implements a git credential helper that keeps
+// credentials, such as HTTPS access tokens, in Delinea Secret Server.
+//
+// Git runs credential helpers with the action get, store or erase and writes
+// the attributes of a credential, such as protocol=https and host=github.com,
+// to their standard input; for get, the helper writes the username and
+// password it found to its standard output.
+// See https://git-scm.com/docs/gitcredentials.
+//
+// Each credential is a secret whose URL field holds the protocol and host of
+// the remote, such as https://github.com, and whose username and password
+// fields hold the credential. Secrets are found by searching the URL field.
+package gitcredential
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/DelineaXPM/tss-sdk-go/v3/server"
+)
+
+// The slugs of the fields that hold credentials unless Helper overrides them.
+// They are those of the Web Password template that ships with Secret Server.
+const (
+	DefaultURLField      = "url"
+	DefaultUsernameField = "username"
+	DefaultPasswordField = "password"
+)
+
+// The actions that git asks credential helpers to perform
+const (
+	ActionGet   = "get"
+	ActionStore = "store"
+	ActionErase = "erase"
+)
+
+// Credential holds the attributes of the git credential protocol that the
+// helper uses; git may send others, which are ignored
+type Credential struct {
+	Protocol, Host, Path, Username, Password string
+}
+
+// URL returns the URL of the remote, as stored in the URL field of secrets,
+// such as "https://github.com" or, if git sent a path, "https://github.com/org/repo.git"
+func (c Credential) URL() string {
+	url := c.Protocol + "://" + c.Host
+	if c.Path != "" {
+		url += "/" + strings.TrimPrefix(c.Path, "/")
+	}
+	return url
+}
+
+// Read reads the attributes of a credential, one "key=value" per line, up to
+// a blank line or the end of the input
+func Read(r io.Reader) (Credential, error) {
+	var c Credential
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == "" {
+			break
+		}
+		i := strings.Index(line, "=")
+		if i < 1 {
+			return c, fmt.Errorf("'%s' is not of the form key=value", line)
+		}
+		key, value := line[:i], line[i+1:]
+		switch key {
+		case "protocol":
+			c.Protocol = value
+		case "host":
+			c.Host = value
+		case "path":
+			c.Path = value
+		case "username":
+			c.Username = value
+		case "password":
+			c.Password = value
+		}
+	}
+	return c, scanner.Err()
+}
+
+// Write writes the username and password of the credential, which is what
+// git expects in answer to get
+func (c Credential) Write(w io.Writer) error {
+	var b strings.Builder
+	for _, attribute := range []struct{ key, value string }{{"username", c.Username}, {"password", c.Password}} {
+		if attribute.value == "" {
+			continue
+		}
+		if strings.ContainsAny(attribute.value, "\n\x00") {
+			return fmt.Errorf("the %s cannot be passed to git, since it contains a newline or NUL character", attribute.key)
+		}
+		fmt.Fprintf(&b, "%s=%s\n", attribute.key, attribute.value)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Helper is a git credential helper backed by Secret Server
+type Helper struct {
+	Secrets server.SecretsAPI
+	// TemplateID, FolderID and SiteID are the template, folder and
+	// distributed engine site of the secrets created by Store; a FolderID of
+	// -1 creates them outside of any folder
+	TemplateID, FolderID, SiteID int
+	// URLField, UsernameField and PasswordField are the slugs of the fields
+	// that hold credentials, DefaultURLField, DefaultUsernameField and
+	// DefaultPasswordField if empty
+	URLField, UsernameField, PasswordField string
+	// DeleteOnErase makes Erase delete the secrets that hold a credential git
+	// rejected. Git erases a credential whenever the remote refuses it, even
+	// once, so by default Erase leaves the secrets alone.
+	DeleteOnErase bool
+}
+
+func (h Helper) urlField() string {
+	if h.URLField == "" {
+		return DefaultURLField
+	}
+	return h.URLField
+}
+
+func (h Helper) usernameField() string {
+	if h.UsernameField == "" {
+		return DefaultUsernameField
+	}
+	return h.UsernameField
+}
+
+func (h Helper) passwordField() string {
+	if h.PasswordField == "" {
+		return DefaultPasswordField
+	}
+	return h.PasswordField
+}
+
+// Run performs the action on the credential read from r, writing the answer
+// to get to w. Actions other than get, store and erase are ignored, as git
+// expects of helpers.
+func (h Helper) Run(action string, r io.Reader, w io.Writer) error {
+	if action != ActionGet && action != ActionStore && action != ActionErase {
+		return nil
+	}
+	c, err := Read(r)
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case ActionGet:
+		found, ok, err := h.Get(c)
+		if err != nil || !ok {
+			return err
+		}
+		return found.Write(w)
+	case ActionStore:
+		return h.Store(c)
+	default:
+		return h.Erase(c)
+	}
+}
+
+// find returns the secrets whose URL field is the URL of the credential and,
+// if the credential has a username, whose username field is that username
+func (h Helper) find(c Credential) ([]server.Secret, error) {
+	if c.Protocol == "" || c.Host == "" {
+		return nil, fmt.Errorf("the credential has no protocol or host")
+	}
+	url := c.URL()
+	secrets, err := h.Secrets.Secrets(url, h.urlField())
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([]server.Secret, 0, len(secrets))
+	for _, secret := range secrets {
+		if value, _ := secret.Field(h.urlField()); !strings.EqualFold(strings.TrimSuffix(value, "/"), url) {
+			continue
+		}
+		if username, _ := secret.Field(h.usernameField()); c.Username != "" && username != c.Username {
+			continue
+		}
+		matches = append(matches, secret)
+	}
+	log.Printf("[DEBUG] %d secrets match %s", len(matches), url)
+	return matches, nil
+}
+
+// Get returns the credential stored for the remote and, if the credential has
+// one, the username. If git sent a path, credentials stored for the path are
+// preferred to those stored for the host. It returns false if there is none.
+func (h Helper) Get(c Credential) (Credential, bool, error) {
+	secrets, err := h.find(c)
+	if err == nil && len(secrets) == 0 && c.Path != "" {
+		c.Path = ""
+		secrets, err = h.find(c)
+	}
+	if err != nil || len(secrets) == 0 {
+		return Credential{}, false, err
+	}
+
+	found := c
+	found.Username, _ = secrets[0].Field(h.usernameField())
+	found.Password, _ = secrets[0].Field(h.passwordField())
+	return found, true, nil
+}
+
+// Store saves the credential, updating the password of the secret that holds
+// the credential for the remote and username, or creating one if there is
+// none
+func (h Helper) Store(c Credential) error {
+	if c.Username == "" || c.Password == "" {
+		return nil
+	}
+	secrets, err := h.find(c)
+	if err != nil {
+		return err
+	}
+
+	if len(secrets) > 0 {
+		secret := secrets[0]
+		password, found := secret.Field(h.passwordField())
+		if !found {
+			return fmt.Errorf("the secret '%s' has no field '%s'", secret.Name, h.passwordField())
+		}
+		if password == c.Password {
+			return nil
+		}
+		return h.Secrets.UpdateField(secret.ID, h.passwordField(), c.Password)
+	}
+
+	if h.TemplateID == 0 {
+		return fmt.Errorf("a secret template is required to store new credentials")
+	}
+	template, err := h.Secrets.SecretTemplate(h.TemplateID)
+	if err != nil {
+		return err
+	}
+	secret, err := server.NewSecretBuilder(template).
+		Name(c.Username+"@"+c.Host).
+		Folder(h.FolderID).
+		Site(h.SiteID).
+		Field(h.urlField(), c.URL()).
+		Field(h.usernameField(), c.Username).
+		Field(h.passwordField(), c.Password).
+		Build()
+	if err != nil {
+		return err
+	}
+	_, err = h.Secrets.CreateSecret(*secret)
+	return err
+}
+
+// Erase does nothing unless DeleteOnErase is set, in which case it deletes
+// the secrets that hold the credential for the remote, the username, if the
+// credential has one, and the password. A credential without a password
+// deletes nothing.
+func (h Helper) Erase(c Credential) error {
+	if !h.DeleteOnErase || c.Password == "" {
+		log.Printf("[DEBUG] not deleting the credentials for %s", c.URL())
+		return nil
+	}
+	secrets, err := h.find(c)
+	if err != nil {
+		return err
+	}
+	for _, secret := range secrets {
+		if password, _ := secret.Field(h.passwordField()); password != c.Password {
+			continue
+		}
+		if err = h.Secrets.DeleteSecret(secret.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
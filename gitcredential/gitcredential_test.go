@@ -0,0 +1,155 @@
+package gitcredential
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/DelineaXPM/tss-sdk-go/v3/server"
+	"github.com/DelineaXPM/tss-sdk-go/v3/tsstest"
+)
+
+// webPasswordTemplate is like the Web Password template that ships with
+// Secret Server
+func webPasswordTemplate() server.SecretTemplate {
+	return server.SecretTemplate{
+		Name: "Web Password",
+		Fields: []server.SecretTemplateField{
+			{Name: "URL", FieldSlugName: "url", IsRequired: true},
+			{Name: "Username", FieldSlugName: "username", IsRequired: true},
+			{Name: "Password", FieldSlugName: "password", IsPassword: true, IsRequired: true},
+			{Name: "Notes", FieldSlugName: "notes", IsNotes: true},
+		},
+	}
+}
+
+func newHelper(t *testing.T) (Helper, *tsstest.Server) {
+	fake := tsstest.NewServer()
+	template := fake.AddTemplate(webPasswordTemplate())
+	tss, err := server.New(fake.Configuration())
+	if err != nil {
+		fake.Close()
+		t.Fatal(err)
+	}
+	return Helper{Secrets: tss, TemplateID: template.ID, FolderID: -1, SiteID: 1}, fake
+}
+
+// run runs the helper with the action and input and returns its output
+func run(t *testing.T, h Helper, action, input string) string {
+	var b strings.Builder
+	if err := h.Run(action, strings.NewReader(input), &b); err != nil {
+		t.Fatalf("%s: %s", action, err)
+	}
+	return b.String()
+}
+
+// TestReadWrite tests the credential protocol
+func TestReadWrite(t *testing.T) {
+	c, err := Read(strings.NewReader("protocol=https\r\nhost=example.com:8443\npath=org/repo.git\nusername=dev\npassword=a=b\ncapability[]=authtype\n\nhost=ignored\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Credential{Protocol: "https", Host: "example.com:8443", Path: "org/repo.git", Username: "dev", Password: "a=b"}
+	if c != expected {
+		t.Errorf("expected %+v, found %+v", expected, c)
+	}
+	if url := c.URL(); url != "https://example.com:8443/org/repo.git" {
+		t.Errorf("expected the URL with the path, found %s", url)
+	}
+	if _, err = Read(strings.NewReader("protocol\n")); err == nil {
+		t.Error("expected an error for a line without '='")
+	}
+
+	var b strings.Builder
+	if err = c.Write(&b); err != nil || b.String() != "username=dev\npassword=a=b\n" {
+		t.Errorf("expected the username and password, found %q (%v)", b.String(), err)
+	}
+	if err = (Credential{Password: "two\nlines"}).Write(&b); err == nil {
+		t.Error("expected an error for a password with a newline")
+	}
+}
+
+// TestStoreGetErase tests the helper against a fake server through storing,
+// getting, updating and erasing a credential
+func TestStoreGetErase(t *testing.T) {
+	h, fake := newHelper(t)
+	defer fake.Close()
+
+	if output := run(t, h, ActionGet, "protocol=https\nhost=github.com\n"); output != "" {
+		t.Errorf("expected no credential before one is stored, found %q", output)
+	}
+
+	run(t, h, ActionStore, "protocol=https\nhost=github.com\nusername=dev\npassword=token-1\n")
+	if output := run(t, h, ActionGet, "protocol=https\nhost=github.com\n"); output != "username=dev\npassword=token-1\n" {
+		t.Errorf("expected the stored credential, found %q", output)
+	}
+	secrets, err := h.Secrets.Secrets("https://github.com", "url")
+	if err != nil || len(secrets) != 1 || secrets[0].Name != "dev@github.com" {
+		t.Fatalf("expected a secret named dev@github.com with the remote as its URL, found %+v (%v)", secrets, err)
+	}
+	id := secrets[0].ID
+
+	// a path falls back to the credential for the host, and another
+	// username does not match
+	if output := run(t, h, ActionGet, "protocol=https\nhost=github.com\npath=org/repo.git\nusername=dev\n"); output != "username=dev\npassword=token-1\n" {
+		t.Errorf("expected the credential for the host, found %q", output)
+	}
+	if output := run(t, h, ActionGet, "protocol=https\nhost=github.com\nusername=other\n"); output != "" {
+		t.Errorf("expected no credential for another username, found %q", output)
+	}
+
+	before := len(fake.Requests())
+	run(t, h, ActionStore, "protocol=https\nhost=github.com\nusername=dev\npassword=token-2\n")
+	for _, request := range fake.Requests()[before:] {
+		if request == fmt.Sprintf("PUT /api/v1/secrets/%d", id) {
+			t.Errorf("expected only the password to be updated, found %s", request)
+		}
+	}
+	if output := run(t, h, ActionGet, "protocol=https\nhost=github.com\nusername=dev\n"); output != "username=dev\npassword=token-2\n" {
+		t.Errorf("expected the updated credential, found %q", output)
+	}
+	if secrets, _ = h.Secrets.Secrets("https://github.com", "url"); len(secrets) != 1 || secrets[0].ID != id {
+		t.Errorf("expected the secret to be updated rather than replaced, found %+v", secrets)
+	}
+
+	run(t, h, ActionErase, "protocol=https\nhost=github.com\nusername=dev\npassword=token-2\n")
+	for _, request := range fake.Requests() {
+		if strings.HasPrefix(request, "DELETE ") {
+			t.Errorf("expected erase to keep the credential unless DeleteOnErase is set, found %s", request)
+		}
+	}
+	if output := run(t, h, ActionGet, "protocol=https\nhost=github.com\n"); output != "username=dev\npassword=token-2\n" {
+		t.Errorf("expected the credential to be kept, found %q", output)
+	}
+
+	h.DeleteOnErase = true
+	run(t, h, ActionErase, "protocol=https\nhost=github.com\nusername=dev\npassword=token-1\n")
+	run(t, h, ActionErase, "protocol=https\nhost=github.com\nusername=dev\n")
+	if output := run(t, h, ActionGet, "protocol=https\nhost=github.com\n"); output == "" {
+		t.Error("expected erase not to delete a credential with another password or without one")
+	}
+	run(t, h, ActionErase, "protocol=https\nhost=github.com\nusername=dev\npassword=token-2\n")
+	if output := run(t, h, ActionGet, "protocol=https\nhost=github.com\n"); output != "" {
+		t.Errorf("expected the credential to be erased, found %q", output)
+	}
+}
+
+// TestIgnoredActions tests that unknown actions and incomplete credentials
+// are ignored
+func TestIgnoredActions(t *testing.T) {
+	h, fake := newHelper(t)
+	defer fake.Close()
+
+	if output := run(t, h, "capabilities", "not=read\n"); output != "" {
+		t.Errorf("expected no output for an unknown action, found %q", output)
+	}
+	run(t, h, ActionStore, "protocol=https\nhost=github.com\nusername=dev\n")
+	if requests := fake.Requests(); len(requests) != 0 {
+		t.Errorf("expected no requests for a credential without a password, found %v", requests)
+	}
+
+	h.TemplateID = 0
+	if err := h.Store(Credential{Protocol: "https", Host: "github.com", Username: "dev", Password: "token"}); err == nil {
+		t.Error("expected an error storing a new credential without a template")
+	}
+}
@@ -0,0 +1,178 @@
+// Package yaml parses and emits the small subset of YAML that the SDK uses:
+// profile files, and the documents written by the output and kubesecret
+// packages.
+package yaml
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Parse parses the subset of YAML used by profile files: nested mappings
+// of plain, single-quoted or double-quoted scalars, with comments. Sequences,
+// flow collections, block scalars, anchors and tags are rejected.
+//
+// Mappings are returned as map[string]interface{} and scalars as strings.
+func Parse(data []byte) (map[string]interface{}, error) {
+	type level struct {
+		// owner is the indentation of the key that owns the mapping, and
+		// indent the indentation of the keys in it, or -1 until one is seen
+		owner, indent int
+		mapping       map[string]interface{}
+	}
+	root := make(map[string]interface{})
+	stack := []*level{{owner: -1, indent: -1, mapping: root}}
+
+	for number, line := range strings.Split(string(data), "\n") {
+		number++
+		line = strings.TrimRight(line, " \r")
+		content := strings.TrimLeft(line, " ")
+		if content == "" || content[0] == '#' || content == "---" {
+			continue
+		}
+		if strings.HasPrefix(content, "\t") {
+			return nil, fmt.Errorf("line %d: tabs cannot be used for indentation", number)
+		}
+		indent := len(line) - len(content)
+
+		for stack[len(stack)-1].owner >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		current := stack[len(stack)-1]
+		if current.indent == -1 {
+			current.indent = indent
+		} else if current.indent != indent {
+			return nil, fmt.Errorf("line %d: inconsistent indentation", number)
+		}
+
+		key, rest, err := yamlKey(content)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", number, err)
+		}
+		if _, duplicate := current.mapping[key]; duplicate {
+			return nil, fmt.Errorf("line %d: duplicate key '%s'", number, key)
+		}
+		if rest == "" {
+			child := make(map[string]interface{})
+			current.mapping[key] = child
+			stack = append(stack, &level{owner: indent, indent: -1, mapping: child})
+			continue
+		}
+		value, err := yamlValue(rest)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", number, err)
+		}
+		current.mapping[key] = value
+	}
+	return root, nil
+}
+
+// yamlKey splits "key: rest" into the key and the rest, which is empty if
+// the key introduces a nested mapping
+func yamlKey(content string) (string, string, error) {
+	if strings.HasPrefix(content, "- ") || content == "-" {
+		return "", "", fmt.Errorf("sequences are not supported")
+	}
+	if content[0] == '"' || content[0] == '\'' {
+		end := closingQuote(content)
+		if end < 0 {
+			return "", "", fmt.Errorf("unterminated quoted key")
+		}
+		key, err := yamlValue(content[:end+1])
+		if err != nil {
+			return "", "", err
+		}
+		rest := content[end+1:]
+		if !strings.HasPrefix(rest, ":") {
+			return "", "", fmt.Errorf("expected ':' after the key")
+		}
+		return key, stripComment(strings.TrimSpace(rest[1:])), nil
+	}
+
+	i := strings.Index(content, ": ")
+	if i < 0 {
+		if !strings.HasSuffix(content, ":") {
+			return "", "", fmt.Errorf("expected 'key: value'")
+		}
+		i = len(content) - 1
+	}
+	return strings.TrimSpace(content[:i]), stripComment(strings.TrimSpace(content[i+1:])), nil
+}
+
+// stripComment removes a trailing comment from an unquoted value
+func stripComment(value string) string {
+	if strings.HasPrefix(value, "#") {
+		return ""
+	}
+	if value == "" || value[0] == '"' || value[0] == '\'' {
+		return value
+	}
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return value
+}
+
+// closingQuote returns the index of the quote that closes the quoted string
+// at the start of s, or -1
+func closingQuote(s string) int {
+	quote := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case quote == '"' && s[i] == '\\':
+			i++
+		case quote == '\'' && s[i] == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++
+		case s[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// yamlValue parses a scalar value
+func yamlValue(value string) (string, error) {
+	switch value[0] {
+	case '"', '\'':
+		end := closingQuote(value)
+		if end < 0 {
+			return "", fmt.Errorf("unterminated quoted value")
+		}
+		if trailing := strings.TrimSpace(value[end+1:]); trailing != "" && !strings.HasPrefix(trailing, "#") {
+			return "", fmt.Errorf("unexpected '%s' after the quoted value", trailing)
+		}
+		if value[0] == '\'' {
+			return strings.Replace(value[1:end], "''", "'", -1), nil
+		}
+		unquoted, err := strconv.Unquote(value[:end+1])
+		if err != nil {
+			return "", fmt.Errorf("invalid double-quoted value %s", value[:end+1])
+		}
+		return unquoted, nil
+	case '[', '{', '&', '*', '!', '|', '>':
+		return "", fmt.Errorf("'%c' is not supported", value[0])
+	}
+	if value == "~" || value == "null" {
+		return "", nil
+	}
+	return value, nil
+}
+
+var (
+	plain    = regexp.MustCompile(`^[A-Za-z_/][A-Za-z0-9 _./@-]*$`)
+	reserved = regexp.MustCompile(`^(?i:y|yes|n|no|true|false|on|off|null|~)$`)
+)
+
+// Scalar renders the value as a YAML scalar, quoting it unless it is
+// unambiguously a plain string
+func Scalar(value string) string {
+	if plain.MatchString(value) && !reserved.MatchString(value) && !strings.HasSuffix(value, " ") {
+		return value
+	}
+	// a JSON string is also a valid YAML double-quoted scalar
+	quoted, _ := json.Marshal(value)
+	return string(quoted)
+}
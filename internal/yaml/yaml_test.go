@@ -0,0 +1,42 @@
+package yaml
+
+import (
+	"testing"
+)
+
+// TestParse tests the parsing of the supported YAML subset
+func TestParse(t *testing.T) {
+	document, err := Parse([]byte("a:\n  b: 1\n  c:\n    d: \"x # y\\n\"\n  e: ~\nf: g # comment\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := document["a"].(map[string]interface{})
+	if a["b"] != "1" || a["c"].(map[string]interface{})["d"] != "x # y\n" || a["e"] != "" || document["f"] != "g" {
+		t.Errorf("unexpected document %v", document)
+	}
+
+	for _, invalid := range []string{"a: 1\na: 2\n", "a:\n  b: 1\n   c: 2\n", "- a\n", "a: [1, 2]\n", "a: \"open\n", "a\n", "a: |\n  text\n"} {
+		if _, err := Parse([]byte(invalid)); err == nil {
+			t.Errorf("expected %q to be rejected", invalid)
+		}
+	}
+}
+
+// TestScalar tests that values are quoted unless they are unambiguously plain
+// strings
+func TestScalar(t *testing.T) {
+	for value, expected := range map[string]string{
+		"app":                "app",
+		"/Prod/DB":           "/Prod/DB",
+		"yes":                `"yes"`,
+		"42":                 `"42"`,
+		"trailing ":          `"trailing "`,
+		"a: b":               `"a: b"`,
+		"line\nbreak":        `"line\nbreak"`,
+		"tss.delinea.com/id": "tss.delinea.com/id",
+	} {
+		if scalar := Scalar(value); scalar != expected {
+			t.Errorf("expected %q to be rendered as %s, found %s", value, expected, scalar)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+// Package kubesecret converts secrets from Delinea Secret Server into
+// Kubernetes Secret (v1.Secret) manifests, so that they can be applied to a
+// cluster without the cluster having access to Secret Server, and without
+// needing a cluster to generate them.
+//
+// Each field of a secret becomes a key of the Kubernetes Secret, named after
+// the slug of the field unless Options.Keys maps it to another key. Text
+// fields are written as stringData and file fields, whose contents may be
+// binary, as base64-encoded data. The manifest records the source secret in
+// its labels and annotations.
+package kubesecret
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/DelineaXPM/tss-sdk-go/v3/internal/yaml"
+	"github.com/DelineaXPM/tss-sdk-go/v3/server"
+)
+
+// The labels and annotations that record the source of a manifest
+const (
+	LabelSecretID        = "tss.delinea.com/secret-id"
+	AnnotationSecretName = "tss.delinea.com/secret-name"
+	AnnotationFolderID   = "tss.delinea.com/folder-id"
+	AnnotationTemplateID = "tss.delinea.com/template-id"
+)
+
+// DefaultType is the type of the Kubernetes Secrets unless Options.Type is set
+const DefaultType = "Opaque"
+
+// Options control how secrets are converted
+type Options struct {
+	// Namespace is the namespace of the Kubernetes Secrets; if empty, they
+	// are created in the namespace they are applied to
+	Namespace string
+	// Name is the name of the Kubernetes Secret, which can only be set when
+	// converting a single secret. By default the name is derived from the
+	// name of the secret.
+	Name string
+	// Type is the type of the Kubernetes Secrets, DefaultType if empty
+	Type string
+	// Keys maps field slugs to the keys of the Kubernetes Secret. Fields that
+	// are not mapped keep their slug, unless OnlyMapped is set. Mapped slugs
+	// that a secret does not have are ignored.
+	Keys map[string]string
+	// OnlyMapped leaves out the fields that are not in Keys
+	OnlyMapped bool
+	// Labels and Annotations are added to those that record the source
+	// secret
+	Labels, Annotations map[string]string
+}
+
+// Manifest is a Kubernetes Secret
+type Manifest struct {
+	APIVersion string     `json:"apiVersion"`
+	Kind       string     `json:"kind"`
+	Metadata   ObjectMeta `json:"metadata"`
+	Type       string     `json:"type"`
+	// Data holds the contents of file fields
+	Data map[string][]byte `json:"data,omitempty"`
+	// StringData holds the values of the other fields
+	StringData map[string]string `json:"stringData,omitempty"`
+}
+
+// ObjectMeta is the metadata of a Kubernetes Secret
+type ObjectMeta struct {
+	Name        string            `json:"name"`
+	Namespace   string            `json:"namespace,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+var (
+	// validKey matches the keys allowed in the data of a Kubernetes Secret
+	validKey = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+	// invalidNameCharacters matches runs of characters that are not allowed
+	// in the names of Kubernetes objects
+	invalidNameCharacters = regexp.MustCompile(`[^a-z0-9.-]+`)
+	// validName matches the names of Kubernetes objects, which are DNS
+	// subdomains: lowercase labels separated by dots, each starting and ending
+	// with a letter or digit
+	validName = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+)
+
+// maxNameLength is the maximum length of the name of a Kubernetes Secret
+const maxNameLength = 253
+
+// ObjectName derives a valid Kubernetes object name (a DNS subdomain) from
+// the name of a secret, for example "Prod DB (admin)" becomes "prod-db-admin"
+// and "Prod. DB" becomes "prod.db". If nothing of the name is left, it is
+// "secret-<id>".
+func ObjectName(name string, id int) string {
+	name = invalidNameCharacters.ReplaceAllString(strings.ToLower(name), "-")
+
+	// each label must start and end with a letter or digit
+	labels := make([]string, 0)
+	for _, label := range strings.Split(name, ".") {
+		if label = strings.Trim(label, "-"); label != "" {
+			labels = append(labels, label)
+		}
+	}
+	name = strings.Join(labels, ".")
+	if len(name) > maxNameLength {
+		name = strings.TrimRight(name[:maxNameLength], "-.")
+	}
+	if name == "" {
+		return fmt.Sprintf("secret-%d", id)
+	}
+	return name
+}
+
+// validObjectName returns whether the name is a valid Kubernetes object name
+func validObjectName(name string) bool {
+	return len(name) <= maxNameLength && validName.MatchString(name)
+}
+
+// FromSecret converts the secret into a Kubernetes Secret. Fields without a
+// value, such as file fields without an attachment, are left out.
+func FromSecret(secret *server.Secret, options Options) (*Manifest, error) {
+	name := options.Name
+	if name == "" {
+		name = ObjectName(secret.Name, secret.ID)
+	} else if !validObjectName(name) {
+		return nil, fmt.Errorf("'%s' is not a valid Kubernetes Secret name", name)
+	}
+	secretType := options.Type
+	if secretType == "" {
+		secretType = DefaultType
+	}
+
+	manifest := &Manifest{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Metadata: ObjectMeta{
+			Name:      name,
+			Namespace: options.Namespace,
+			Labels:    map[string]string{LabelSecretID: strconv.Itoa(secret.ID)},
+			Annotations: map[string]string{
+				AnnotationSecretName: secret.Name,
+				AnnotationFolderID:   strconv.Itoa(secret.FolderID),
+				AnnotationTemplateID: strconv.Itoa(secret.SecretTemplateID),
+			},
+		},
+		Type: secretType,
+	}
+	for key, value := range options.Labels {
+		manifest.Metadata.Labels[key] = value
+	}
+	for key, value := range options.Annotations {
+		manifest.Metadata.Annotations[key] = value
+	}
+
+	slugs := make(map[string]string)
+	for _, field := range secret.Fields {
+		key, mapped := options.Keys[field.Slug]
+		if !mapped {
+			if options.OnlyMapped {
+				continue
+			}
+			key = field.Slug
+		}
+		if field.ItemValue == "" || (field.IsFile && field.FileAttachmentID == 0) {
+			continue
+		}
+		if !validKey.MatchString(key) {
+			return nil, fmt.Errorf("'%s' is not a valid Kubernetes Secret key for the field '%s' of the secret '%s'", key, field.Slug, secret.Name)
+		}
+		if other, duplicate := slugs[key]; duplicate {
+			return nil, fmt.Errorf("the fields '%s' and '%s' of the secret '%s' both map to the key '%s'", other, field.Slug, secret.Name, key)
+		}
+		slugs[key] = field.Slug
+
+		if field.IsFile {
+			if manifest.Data == nil {
+				manifest.Data = make(map[string][]byte)
+			}
+			manifest.Data[key] = []byte(field.ItemValue)
+		} else {
+			if manifest.StringData == nil {
+				manifest.StringData = make(map[string]string)
+			}
+			manifest.StringData[key] = field.ItemValue
+		}
+	}
+	return manifest, nil
+}
+
+// FromSecretID gets the secret with id and converts it into a Kubernetes
+// Secret
+func FromSecretID(api server.SecretsAPI, id int, options Options) (*Manifest, error) {
+	secret, err := api.Secret(id)
+	if err != nil {
+		return nil, err
+	}
+	return FromSecret(secret, options)
+}
+
+// FromFolder converts the secrets in the folder with the given id and, if
+// includeSubfolders is set, in its subfolders, into Kubernetes Secrets. It
+// fails if two of the secrets would have the same name.
+func FromFolder(api server.SecretsAPI, folderID int, includeSubfolders bool, options Options) ([]*Manifest, error) {
+	if options.Name != "" {
+		return nil, fmt.Errorf("a name can only be given when converting a single secret")
+	}
+	secrets, err := api.FolderSecrets(folderID, includeSubfolders)
+	if err != nil {
+		return nil, err
+	}
+
+	manifests := make([]*Manifest, len(secrets))
+	names := make(map[string]string, len(secrets))
+	for i := range secrets {
+		if manifests[i], err = FromSecret(&secrets[i], options); err != nil {
+			return nil, err
+		}
+		name := manifests[i].Metadata.Name
+		if other, duplicate := names[name]; duplicate {
+			return nil, fmt.Errorf("the secrets '%s' and '%s' both have the Kubernetes Secret name '%s'", other, secrets[i].Name, name)
+		}
+		names[name] = secrets[i].Name
+	}
+	return manifests, nil
+}
+
+// WriteYAML writes the manifests to w as a stream of YAML documents
+func WriteYAML(w io.Writer, manifests ...*Manifest) error {
+	for i, manifest := range manifests {
+		if i > 0 {
+			if _, err := io.WriteString(w, "---\n"); err != nil {
+				return err
+			}
+		}
+		if err := manifest.WriteYAML(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteYAML writes the manifest to w as a YAML document
+func (m *Manifest) WriteYAML(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "apiVersion: %s\n", yaml.Scalar(m.APIVersion))
+	fmt.Fprintf(&b, "kind: %s\n", yaml.Scalar(m.Kind))
+	b.WriteString("metadata:\n")
+	fmt.Fprintf(&b, "  name: %s\n", yaml.Scalar(m.Metadata.Name))
+	if m.Metadata.Namespace != "" {
+		fmt.Fprintf(&b, "  namespace: %s\n", yaml.Scalar(m.Metadata.Namespace))
+	}
+	writeYAMLMapping(&b, "  ", "labels", m.Metadata.Labels)
+	writeYAMLMapping(&b, "  ", "annotations", m.Metadata.Annotations)
+	fmt.Fprintf(&b, "type: %s\n", yaml.Scalar(m.Type))
+	if len(m.Data) > 0 {
+		data := make(map[string]string, len(m.Data))
+		for key, value := range m.Data {
+			data[key] = base64.StdEncoding.EncodeToString(value)
+		}
+		writeYAMLMapping(&b, "", "data", data)
+	}
+	writeYAMLMapping(&b, "", "stringData", m.StringData)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeYAMLMapping writes the mapping, in the order of its keys, under the
+// given key, unless it is empty
+func writeYAMLMapping(b *strings.Builder, indent, key string, mapping map[string]string) {
+	if len(mapping) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(mapping))
+	for k := range mapping {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fmt.Fprintf(b, "%s%s:\n", indent, key)
+	for _, k := range keys {
+		fmt.Fprintf(b, "%s  %s: %s\n", indent, yaml.Scalar(k), yaml.Scalar(mapping[k]))
+	}
+}
@@ -0,0 +1,169 @@
+package kubesecret
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DelineaXPM/tss-sdk-go/v3/server"
+	"github.com/DelineaXPM/tss-sdk-go/v3/tsstest"
+)
+
+func testSecret() *server.Secret {
+	return &server.Secret{
+		ID: 42, Name: "Prod DB (admin)", FolderID: 7, SecretTemplateID: 3,
+		Fields: []server.SecretField{
+			{Slug: "username", ItemValue: "admin"},
+			{Slug: "password", ItemValue: "s3cret: yes", IsPassword: true},
+			{Slug: "notes", ItemValue: "", IsNotes: true},
+			{Slug: "private-key", ItemValue: "\x00\x01key", IsFile: true, FileAttachmentID: 9, Filename: "id_rsa"},
+			{Slug: "public-key", ItemValue: "", IsFile: true},
+		},
+	}
+}
+
+// TestFromSecret tests the conversion of a secret with the default options
+func TestFromSecret(t *testing.T) {
+	manifest, err := FromSecret(testSecret(), Options{Namespace: "apps"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b strings.Builder
+	if err = manifest.WriteYAML(&b); err != nil {
+		t.Fatal(err)
+	}
+	expected := `apiVersion: v1
+kind: Secret
+metadata:
+  name: prod-db-admin
+  namespace: apps
+  labels:
+    tss.delinea.com/secret-id: "42"
+  annotations:
+    tss.delinea.com/folder-id: "7"
+    tss.delinea.com/secret-name: "Prod DB (admin)"
+    tss.delinea.com/template-id: "3"
+type: Opaque
+data:
+  private-key: "AAFrZXk="
+stringData:
+  password: "s3cret: yes"
+  username: admin
+`
+	if b.String() != expected {
+		t.Errorf("expected\n%s\nfound\n%s", expected, b.String())
+	}
+}
+
+// TestFromSecretOptions tests key mapping, extra metadata and the errors for
+// invalid names and keys
+func TestFromSecretOptions(t *testing.T) {
+	manifest, err := FromSecret(testSecret(), Options{
+		Name:        "db-credentials",
+		Type:        "kubernetes.io/basic-auth",
+		Keys:        map[string]string{"password": "PASSWORD", "private-key": "ssh-privatekey", "missing": "ignored"},
+		OnlyMapped:  true,
+		Labels:      map[string]string{"app": "db"},
+		Annotations: map[string]string{"owner": "team"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Metadata.Name != "db-credentials" || manifest.Type != "kubernetes.io/basic-auth" {
+		t.Errorf("expected the given name and type, found %q and %q", manifest.Metadata.Name, manifest.Type)
+	}
+	if len(manifest.StringData) != 1 || manifest.StringData["PASSWORD"] != "s3cret: yes" {
+		t.Errorf("expected only the mapped password, found %v", manifest.StringData)
+	}
+	if string(manifest.Data["ssh-privatekey"]) != "\x00\x01key" {
+		t.Errorf("expected the mapped file, found %v", manifest.Data)
+	}
+	if manifest.Metadata.Labels["app"] != "db" || manifest.Metadata.Labels[LabelSecretID] != "42" {
+		t.Errorf("expected the labels to be merged, found %v", manifest.Metadata.Labels)
+	}
+	if manifest.Metadata.Annotations["owner"] != "team" || manifest.Metadata.Annotations[AnnotationSecretName] != "Prod DB (admin)" {
+		t.Errorf("expected the annotations to be merged, found %v", manifest.Metadata.Annotations)
+	}
+
+	for _, options := range []Options{
+		{Name: "Not Valid"},
+		{Name: "prod.-db"},
+		{Name: "a..b"},
+		{Name: "db-"},
+		{Name: strings.Repeat("a", maxNameLength+1)},
+		{Keys: map[string]string{"username": "user name"}},
+		{Keys: map[string]string{"username": "password"}},
+	} {
+		if _, err := FromSecret(testSecret(), options); err == nil {
+			t.Errorf("expected an error for %+v", options)
+		}
+	}
+}
+
+// TestObjectName tests the derivation of Kubernetes names from secret names
+func TestObjectName(t *testing.T) {
+	for name, expected := range map[string]string{
+		"Prod DB (admin)":               "prod-db-admin",
+		"api.example.com":               "api.example.com",
+		"  --Ünïcode--  ":               "n-code",
+		"***":                           "secret-5",
+		"Prod. DB":                      "prod.db",
+		"a..b":                          "a.b",
+		"a.-b":                          "a.b",
+		"-a-.b-":                        "a.b",
+		".":                             "secret-5",
+		strings.Repeat("a", 300):        strings.Repeat("a", maxNameLength),
+		strings.Repeat("a", 252) + ".b": strings.Repeat("a", 252),
+	} {
+		if found := ObjectName(name, 5); found != expected {
+			t.Errorf("expected %q for %q, found %q", expected, name, found)
+		}
+		if !validObjectName(ObjectName(name, 5)) {
+			t.Errorf("expected a valid name for %q", name)
+		}
+	}
+}
+
+// TestFromFolder tests the conversion of the secrets in a folder, with and
+// without its subfolders, from a fake server
+func TestFromFolder(t *testing.T) {
+	fake := tsstest.NewServer()
+	defer fake.Close()
+	template := fake.AddTemplate(tsstest.PasswordTemplate())
+	fake.AddFolder(7, "/Prod")
+	fake.AddFolder(8, "/Prod/Databases")
+	fake.AddFolder(9, "/Test")
+	for folderID, name := range map[int]string{7: "API", 8: "DB", 9: "Other"} {
+		if _, err := fake.AddSecret(server.Secret{Name: name, FolderID: folderID, SecretTemplateID: template.ID,
+			Fields: []server.SecretField{{Slug: "username", ItemValue: "app"}, {Slug: "password", ItemValue: name + "-password"}}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tss, err := server.New(fake.Configuration())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manifests, err := FromFolder(tss, 7, false, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifests) != 1 || manifests[0].Metadata.Name != "api" || manifests[0].StringData["password"] != "API-password" {
+		t.Errorf("expected the secret in the folder, found %+v", manifests)
+	}
+
+	if manifests, err = FromFolder(tss, 7, true, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if err = WriteYAML(&b, manifests...); err != nil {
+		t.Fatal(err)
+	}
+	if documents := strings.Split(b.String(), "---\n"); len(documents) != 2 || !strings.Contains(documents[1], "name: db\n") {
+		t.Errorf("expected the secrets in the folder and its subfolder, found\n%s", b.String())
+	}
+
+	if _, err = FromFolder(tss, 7, true, Options{Name: "one"}); err == nil {
+		t.Error("expected an error for a name given for a folder")
+	}
+}
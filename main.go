@@ -3,19 +3,18 @@ package main
 import (
 	"fmt"
 	"log"
-	"os"
 
 	"github.com/DelineaXPM/tss-sdk-go/v3/server"
 )
 
 func main() {
-	tss, err := server.New(server.Configuration{
-		Credentials: server.UserCredential{
-			Username: os.Getenv("TSS_USERNAME"),
-			Password: os.Getenv("TSS_PASSWORD"),
-		},
-		Tenant: os.Getenv("TSS_TENANT"),
-	})
+	config, err := server.ConfigurationFromEnv()
+
+	if err != nil {
+		log.Fatal("Error reading the server configuration", err)
+	}
+
+	tss, err := server.New(*config)
 
 	if err != nil {
 		log.Fatal("Error initializing the server configuration", err)
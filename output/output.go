@@ -0,0 +1,265 @@
+// Package output renders secrets from Delinea Secret Server in formats
+// suitable for people and for other programs: JSON, YAML, dotenv files, shell
+// export statements and tables.
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/DelineaXPM/tss-sdk-go/v3/internal/yaml"
+	"github.com/DelineaXPM/tss-sdk-go/v3/server"
+)
+
+// Format is an output format
+type Format string
+
+// The supported output formats
+const (
+	JSON   Format = "json"
+	YAML   Format = "yaml"
+	Dotenv Format = "dotenv"
+	Shell  Format = "shell"
+	Table  Format = "table"
+)
+
+// Formats lists the supported output formats
+var Formats = []Format{JSON, YAML, Dotenv, Shell, Table}
+
+// Mask replaces the values of password and file fields, such as private keys,
+// unless they are revealed
+const Mask = "********"
+
+// Options control how secrets are rendered
+type Options struct {
+	// Reveal renders the values of password and file fields instead of masking
+	// them
+	Reveal bool
+	// Prefix is prepended to the environment variable names of the dotenv
+	// and shell formats
+	Prefix string
+	// Names maps field slugs to environment variable names, overriding the
+	// names derived from the slugs
+	Names map[string]string
+}
+
+// ParseFormat returns the Format with the given name
+func ParseFormat(name string) (Format, error) {
+	for _, format := range Formats {
+		if strings.EqualFold(name, string(format)) {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unknown output format '%s'", name)
+}
+
+var nonAlphanumeric = regexp.MustCompile("[^A-Za-z0-9]+")
+
+// EnvName derives an environment variable name from a field slug, for example
+// "private-key" becomes "PRIVATE_KEY"
+func EnvName(slug string) string {
+	name := strings.ToUpper(strings.Trim(nonAlphanumeric.ReplaceAllString(slug, "_"), "_"))
+	if name != "" && name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+// field is a field of a secret prepared for rendering
+type field struct {
+	slug, value string
+	isFile      bool
+	filename    string
+	// size is the length of the contents of a file field, which may be masked
+	size int
+}
+
+// document is a secret prepared for rendering
+type document struct {
+	ID               int               `json:"id"`
+	Name             string            `json:"name"`
+	FolderID         int               `json:"folderId"`
+	SecretTemplateID int               `json:"secretTemplateId"`
+	Active           bool              `json:"active"`
+	Fields           map[string]string `json:"fields"`
+	fields           []field
+}
+
+func newDocument(secret *server.Secret, options Options) document {
+	doc := document{
+		ID:               secret.ID,
+		Name:             secret.Name,
+		FolderID:         secret.FolderID,
+		SecretTemplateID: secret.SecretTemplateID,
+		Active:           secret.Active,
+		Fields:           make(map[string]string, len(secret.Fields)),
+	}
+	for _, secretField := range secret.Fields {
+		slug := secretField.Slug
+		if slug == "" {
+			slug = secretField.FieldName
+		}
+		value := secretField.ItemValue
+		if (secretField.IsPassword || secretField.IsFile) && !options.Reveal && value != "" {
+			value = Mask
+		}
+		doc.Fields[slug] = value
+		doc.fields = append(doc.fields, field{slug: slug, value: value, isFile: secretField.IsFile,
+			filename: secretField.Filename, size: len(secretField.ItemValue)})
+	}
+	return doc
+}
+
+// WriteSecret renders the secret to w in the given format
+func WriteSecret(w io.Writer, format Format, secret *server.Secret, options Options) error {
+	doc := newDocument(secret, options)
+
+	switch format {
+	case JSON:
+		return writeJSON(w, doc)
+	case YAML:
+		return writeYAMLDocument(w, doc, "")
+	case Dotenv, Shell:
+		return writeEnv(w, format, doc, options)
+	case Table:
+		table := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		fmt.Fprintf(table, "FIELD\tVALUE\n")
+		for _, f := range doc.fields {
+			value := f.value
+			if f.isFile && value != "" {
+				value = fmt.Sprintf("(file %s, %d bytes)", f.filename, f.size)
+			} else if strings.ContainsAny(value, "\r\n") {
+				value = strconv.Quote(value)
+			}
+			fmt.Fprintf(table, "%s\t%s\n", f.slug, value)
+		}
+		return table.Flush()
+	}
+	return fmt.Errorf("unknown output format '%s'", format)
+}
+
+// WriteSecrets renders the secrets, such as the result of a search, to w in
+// the given format. The dotenv and shell formats only support one secret.
+func WriteSecrets(w io.Writer, format Format, secrets []server.Secret, options Options) error {
+	docs := make([]document, len(secrets))
+	for i := range secrets {
+		docs[i] = newDocument(&secrets[i], options)
+	}
+
+	switch format {
+	case JSON:
+		return writeJSON(w, docs)
+	case YAML:
+		if len(docs) == 0 {
+			_, err := fmt.Fprintln(w, "[]")
+			return err
+		}
+		for _, doc := range docs {
+			if err := writeYAMLDocument(w, doc, "- "); err != nil {
+				return err
+			}
+		}
+		return nil
+	case Dotenv, Shell:
+		if len(secrets) != 1 {
+			return fmt.Errorf("the %s format requires exactly one secret, but there are %d", format, len(secrets))
+		}
+		return writeEnv(w, format, docs[0], options)
+	case Table:
+		table := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(table, "ID\tNAME\tFOLDER\tTEMPLATE")
+		for _, doc := range docs {
+			fmt.Fprintf(table, "%d\t%s\t%d\t%d\n", doc.ID, doc.Name, doc.FolderID, doc.SecretTemplateID)
+		}
+		return table.Flush()
+	}
+	return fmt.Errorf("unknown output format '%s'", format)
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+// writeYAMLDocument writes the document as a YAML mapping. If item is not
+// empty, the mapping is written as an item of a sequence. It returns the
+// first error writing to w.
+func writeYAMLDocument(w io.Writer, doc document, item string) error {
+	indent := ""
+	first := item
+	if item != "" {
+		indent = "  "
+	}
+	var err error
+	line := func(format string, args ...interface{}) {
+		prefix := indent
+		if first != "" {
+			prefix, first = first, ""
+		}
+		if err == nil {
+			_, err = fmt.Fprintf(w, prefix+format+"\n", args...)
+		}
+	}
+
+	line("id: %d", doc.ID)
+	line("name: %s", yaml.Scalar(doc.Name))
+	line("folderId: %d", doc.FolderID)
+	line("secretTemplateId: %d", doc.SecretTemplateID)
+	line("active: %t", doc.Active)
+	if len(doc.fields) == 0 {
+		line("fields: {}")
+		return err
+	}
+	line("fields:")
+	for _, f := range doc.fields {
+		line("  %s: %s", yaml.Scalar(f.slug), yaml.Scalar(f.value))
+	}
+	return err
+}
+
+// writeEnv writes a KEY=value line, or an export statement, for every field
+// of the document
+func writeEnv(w io.Writer, format Format, doc document, options Options) error {
+	for _, f := range doc.fields {
+		name, found := options.Names[f.slug]
+		if !found {
+			name = options.Prefix + EnvName(f.slug)
+		}
+		var err error
+		if format == Shell {
+			_, err = fmt.Fprintf(w, "export %s=%s\n", name, shellQuote(f.value))
+		} else {
+			_, err = fmt.Fprintf(w, "%s=%s\n", name, dotenvQuote(f.value))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var dotenvPlain = regexp.MustCompile(`^[A-Za-z0-9_./:@+,-]*$`)
+
+// dotenvQuote double-quotes the value unless it contains only characters
+// that need no quoting, escaping the characters that dotenv parsers expand
+func dotenvQuote(value string) string {
+	if dotenvPlain.MatchString(value) {
+		return value
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "\n", `\n`, "\r", `\r`)
+	return `"` + replacer.Replace(value) + `"`
+}
+
+// shellQuote single-quotes the value for a POSIX shell
+func shellQuote(value string) string {
+	return "'" + strings.Replace(value, "'", `'\''`, -1) + "'"
+}
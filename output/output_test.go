@@ -0,0 +1,119 @@
+package output
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/DelineaXPM/tss-sdk-go/v3/server"
+)
+
+var testSecret = server.Secret{
+	ID:               42,
+	Name:             "db: primary",
+	FolderID:         7,
+	SecretTemplateID: 6,
+	Active:           true,
+	Fields: []server.SecretField{
+		{Slug: "username", ItemValue: "admin"},
+		{Slug: "password", ItemValue: "it's $ecret", IsPassword: true},
+		{Slug: "notes", ItemValue: "line one\nline two"},
+		{Slug: "private-key", ItemValue: "PRIVATE $ecret KEY", IsFile: true, Filename: "id_rsa"},
+	},
+}
+
+func render(t *testing.T, format Format, options Options) string {
+	var buffer bytes.Buffer
+	if err := WriteSecret(&buffer, format, &testSecret, options); err != nil {
+		t.Fatalf("rendering %s: %s", format, err)
+	}
+	return buffer.String()
+}
+
+// TestWriteSecret tests the rendering of a secret in each format
+func TestWriteSecret(t *testing.T) {
+	tests := []struct {
+		format   Format
+		options  Options
+		expected []string
+	}{
+		{JSON, Options{}, []string{`"id": 42`, `"password": "********"`, `"notes": "line one\nline two"`, `"private-key": "********"`}},
+		{YAML, Options{}, []string{"id: 42\n", `name: "db: primary"`, "  username: admin\n", `  password: "********"`, `  private-key: "********"`}},
+		{YAML, Options{Reveal: true}, []string{`  private-key: "PRIVATE $ecret KEY"`}},
+		{Dotenv, Options{Reveal: true}, []string{"USERNAME=admin\n", `PASSWORD="it's \$ecret"`, `NOTES="line one\nline two"`}},
+		{Shell, Options{Reveal: true, Prefix: "DB_"}, []string{"export DB_USERNAME='admin'\n", `export DB_PASSWORD='it'\''s $ecret'`}},
+		{Dotenv, Options{Names: map[string]string{"username": "DB_USER"}}, []string{"DB_USER=admin\n", `PASSWORD="********"`}},
+		{Table, Options{}, []string{"username     admin", `notes        "line one\nline two"`, "private-key  (file id_rsa, 18 bytes)"}},
+	}
+	for _, test := range tests {
+		rendered := render(t, test.format, test.options)
+		for _, expected := range test.expected {
+			if !strings.Contains(rendered, expected) {
+				t.Errorf("expected the %s output to contain %q, found:\n%s", test.format, expected, rendered)
+			}
+		}
+		if !test.options.Reveal && strings.Contains(rendered, "$ecret") {
+			t.Errorf("expected the %s output to mask the password and private key, found:\n%s", test.format, rendered)
+		}
+	}
+}
+
+// TestWriteSecrets tests the rendering of a list of secrets
+func TestWriteSecrets(t *testing.T) {
+	secrets := []server.Secret{testSecret, {ID: 43, Name: "other"}}
+
+	var buffer bytes.Buffer
+	if err := WriteSecrets(&buffer, YAML, secrets, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buffer.String(), "- id: 42\n  name:") || !strings.Contains(buffer.String(), "- id: 43\n  name: other\n") {
+		t.Errorf("unexpected YAML sequence:\n%s", buffer.String())
+	}
+
+	if err := WriteSecrets(&buffer, Dotenv, secrets, Options{}); err == nil {
+		t.Error("expected the dotenv format to reject more than one secret")
+	}
+}
+
+// failingWriter fails every write after the first n bytes
+type failingWriter struct {
+	n int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		written := w.n
+		w.n = 0
+		return written, errors.New("disk full")
+	}
+	w.n -= len(p)
+	return len(p), nil
+}
+
+// TestWriteYAMLError tests that errors writing YAML are returned
+func TestWriteYAMLError(t *testing.T) {
+	for _, n := range []int{0, 20, 80} {
+		if err := WriteSecret(&failingWriter{n: n}, YAML, &testSecret, Options{}); err == nil || err.Error() != "disk full" {
+			t.Errorf("expected the write error after %d bytes, found %v", n, err)
+		}
+		err := WriteSecrets(&failingWriter{n: n}, YAML, []server.Secret{testSecret, testSecret}, Options{})
+		if err == nil || err.Error() != "disk full" {
+			t.Errorf("expected the write error after %d bytes for a list, found %v", n, err)
+		}
+	}
+}
+
+// TestEnvName tests the derivation of environment variable names from slugs
+func TestEnvName(t *testing.T) {
+	for slug, expected := range map[string]string{
+		"password":       "PASSWORD",
+		"private-key":    "PRIVATE_KEY",
+		"Resource Name ": "RESOURCE_NAME",
+		"2fa-code":       "_2FA_CODE",
+	} {
+		if name := EnvName(slug); name != expected {
+			t.Errorf("expected '%s' to become '%s', found '%s'", slug, expected, name)
+		}
+	}
+}
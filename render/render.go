@@ -0,0 +1,229 @@
+// Package render renders text/template templates, such as configuration
+// files, with the values of secrets from Delinea Secret Server.
+//
+// Templates look secrets up with these functions:
+//
+//	{{ secret 42 "password" }}              a field of the secret with id 42
+//	{{ secretByPath "/Prod/DB" "username" }} a field of the secret at a path
+//	{{ file 42 "private-key" }}             the contents of a file field
+//
+// Each secret is fetched once per rendering, however often it is used.
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/DelineaXPM/tss-sdk-go/v3/server"
+)
+
+// Template is a parsed template that can be rendered repeatedly
+type Template struct {
+	api      server.SecretsAPI
+	template *template.Template
+
+	// mutex serializes renderings, which share secrets
+	mutex   sync.Mutex
+	secrets map[string]*server.Secret
+}
+
+// Parse parses the template text, which looks secrets up with api
+func Parse(api server.SecretsAPI, name, text string) (*Template, error) {
+	t := &Template{api: api}
+	parsed, err := template.New(name).Funcs(template.FuncMap{
+		"secret":       t.secretField,
+		"secretByPath": t.secretFieldByPath,
+		"file":         t.fileField,
+	}).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	t.template = parsed
+	return t, nil
+}
+
+// ParseFile parses the template in the file at path
+func ParseFile(api server.SecretsAPI, path string) (*Template, error) {
+	text, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(api, filepath.Base(path), string(text))
+}
+
+// Execute renders the template to w, fetching the secrets it uses
+func (t *Template) Execute(w io.Writer) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.secrets = make(map[string]*server.Secret)
+	defer func() { t.secrets = nil }()
+	return t.template.Execute(w, nil)
+}
+
+// Render renders the template and returns the result
+func (t *Template) Render() ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := t.Execute(&buffer); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// fetch gets the secret with the given key from the secrets of the current
+// rendering, or from the server
+func (t *Template) fetch(key string, get func() (*server.Secret, error)) (*server.Secret, error) {
+	if secret, found := t.secrets[key]; found {
+		return secret, nil
+	}
+	secret, err := get()
+	if err != nil {
+		return nil, err
+	}
+	t.secrets[key] = secret
+	return secret, nil
+}
+
+func (t *Template) secretByID(id int) (*server.Secret, error) {
+	return t.fetch("id:"+strconv.Itoa(id), func() (*server.Secret, error) { return t.api.Secret(id) })
+}
+
+func (t *Template) secretByPath(path string) (*server.Secret, error) {
+	return t.fetch("path:"+path, func() (*server.Secret, error) { return t.api.SecretByPath(path) })
+}
+
+// field returns the value of the named field of the secret, which must be a
+// file field if file is set
+func field(secret *server.Secret, name string, file bool) (string, error) {
+	for _, f := range secret.Fields {
+		if f.Slug != name && f.FieldName != name {
+			continue
+		}
+		if file && !f.IsFile {
+			return "", fmt.Errorf("the field '%s' of the secret '%s' is not a file field", name, secret.Name)
+		}
+		return f.ItemValue, nil
+	}
+	return "", fmt.Errorf("the secret '%s' has no field '%s'", secret.Name, name)
+}
+
+func (t *Template) secretField(id int, name string) (string, error) {
+	secret, err := t.secretByID(id)
+	if err != nil {
+		return "", err
+	}
+	return field(secret, name, false)
+}
+
+func (t *Template) secretFieldByPath(path, name string) (string, error) {
+	secret, err := t.secretByPath(path)
+	if err != nil {
+		return "", err
+	}
+	return field(secret, name, false)
+}
+
+func (t *Template) fileField(id int, name string) (string, error) {
+	secret, err := t.secretByID(id)
+	if err != nil {
+		return "", err
+	}
+	return field(secret, name, true)
+}
+
+// WriteFile renders the template to the file at path, readable only by its
+// owner. The file is replaced atomically, so readers never see a partial
+// rendering.
+func (t *Template) WriteFile(path string) error {
+	rendered, err := t.Render()
+	if err != nil {
+		return err
+	}
+	return writeFile(path, rendered)
+}
+
+func writeFile(path string, data []byte) error {
+	temp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+
+	if _, err = temp.Write(data); err == nil {
+		err = temp.Chmod(0600)
+	}
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), path)
+}
+
+// Watch renders the template to the file at path, then re-renders it every
+// interval until stop is closed. Whenever the rendering differs from the file,
+// which is when a secret the template uses has changed, the file is replaced
+// and onChange, if not nil, is called.
+//
+// An error in the first rendering is returned, as is an interval that is not
+// positive. Later errors, such as the server being unavailable, are passed to
+// onError, if not nil, or else logged, and the rendering is retried after the
+// next interval.
+func (t *Template) Watch(path string, interval time.Duration, stop <-chan struct{}, onChange func() error, onError func(error)) error {
+	if interval <= 0 {
+		return fmt.Errorf("the interval of watching %s must be positive, not %s", path, interval)
+	}
+
+	// Render returns nil for an empty rendering, so whether the file has been
+	// written is tracked separately
+	var previous []byte
+	written := false
+	update := func() error {
+		rendered, err := t.Render()
+		if err != nil {
+			return err
+		}
+		if written && bytes.Equal(rendered, previous) {
+			return nil
+		}
+		if err = writeFile(path, rendered); err != nil {
+			return err
+		}
+		previous, written = rendered, true
+		log.Printf("[DEBUG] rendered %s", path)
+		if onChange != nil {
+			return onChange()
+		}
+		return nil
+	}
+
+	if err := update(); err != nil {
+		return err
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+			if err := update(); err == nil {
+				continue
+			} else if onError != nil {
+				onError(err)
+			} else {
+				log.Printf("[ERROR] rendering %s: %s", path, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package render
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DelineaXPM/tss-sdk-go/v3/server"
+	"github.com/DelineaXPM/tss-sdk-go/v3/tsstest"
+)
+
+// fixtures holds the SSH key secret /Prod/DB, whose id is 42
+func fixtures() tsstest.Fixtures {
+	template := tsstest.SshKeyTemplate()
+	template.ID = 6002
+	return tsstest.Fixtures{
+		Templates: []server.SecretTemplate{template},
+		Folders:   map[int]string{7: "/Prod"},
+		Secrets: []server.Secret{{ID: 42, Name: "DB", SecretTemplateID: template.ID, FolderID: 7,
+			Fields: []server.SecretField{
+				{Slug: "username", ItemValue: "app"},
+				{Slug: "password", ItemValue: "s3cret"},
+				{Slug: "private-key", ItemValue: "KEY", Filename: "id_rsa"},
+			}}},
+	}
+}
+
+// lookups returns the number of secrets the mock has looked up
+func lookups(api *tsstest.SecretsAPIMock) int {
+	return len(api.SecretCalls()) + len(api.SecretByPathCalls())
+}
+
+// TestRender tests the template functions and that secrets are fetched once
+func TestRender(t *testing.T) {
+	fake, _, api, err := tsstest.NewSecretsAPI(fixtures())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fake.Close()
+	tmpl, err := Parse(api, "test", `{{ secretByPath "/Prod/DB" "username" }}:{{ secret 42 "password" }} {{ file 42 "private-key" }} {{ secret 42 "username" }}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rendered, err := tmpl.Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(rendered) != "app:s3cret KEY app" {
+		t.Errorf("unexpected rendering %q", rendered)
+	}
+	if lookups(api) != 2 {
+		t.Errorf("expected 2 lookups, one by path and one by id, found %d", lookups(api))
+	}
+
+	if _, err = tmpl.Render(); err != nil || lookups(api) != 4 {
+		t.Errorf("expected a second rendering to fetch the secrets again, found %d lookups", lookups(api))
+	}
+
+	for _, text := range []string{`{{ secret 42 "missing" }}`, `{{ file 42 "password" }}`, `{{ secret 7 "password" }}`} {
+		tmpl, err := Parse(api, "test", text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = tmpl.Render(); err == nil {
+			t.Errorf("expected rendering %s to fail", text)
+		}
+	}
+}
+
+// TestWatch tests that the file is rewritten and the command run only when
+// a secret changes
+func TestWatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "render")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.conf")
+
+	fake, tss, api, err := tsstest.NewSecretsAPI(fixtures())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fake.Close()
+	tmpl, err := Parse(api, "test", `password={{ secret 42 "password" }}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes := make(chan string, 10)
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- tmpl.Watch(path, 10*time.Millisecond, stop, func() error {
+			contents, err := ioutil.ReadFile(path)
+			changes <- string(contents)
+			return err
+		}, nil)
+	}()
+
+	if contents := <-changes; contents != "password=s3cret" {
+		t.Errorf("unexpected first rendering %q", contents)
+	}
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case contents := <-changes:
+		t.Errorf("unexpected rendering %q while the secret was unchanged", contents)
+	default:
+	}
+
+	if err := tss.UpdateField(42, "password", "changed"); err != nil {
+		t.Fatal(err)
+	}
+	if contents := <-changes; contents != "password=changed" {
+		t.Errorf("unexpected rendering %q after the secret changed", contents)
+	}
+	close(stop)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("expected the rendered file to be readable only by its owner, found %v", info.Mode())
+	}
+}
+
+// TestWatchEmpty tests that an empty rendering is written once, not on every
+// interval
+func TestWatchEmpty(t *testing.T) {
+	dir, err := ioutil.TempDir("", "render")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.conf")
+
+	tmpl, err := Parse(&tsstest.SecretsAPIMock{}, "test", `{{ if false }}unused{{ end }}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes := 0
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- tmpl.Watch(path, 5*time.Millisecond, stop, func() error {
+			changes++
+			return nil
+		}, nil)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	close(stop)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	if changes != 1 {
+		t.Errorf("expected the empty rendering to be written once, found %d changes", changes)
+	}
+	if contents, err := ioutil.ReadFile(path); err != nil || len(contents) != 0 {
+		t.Errorf("expected an empty file, found %q (%v)", contents, err)
+	}
+}
+
+// TestWatchErrors tests that Watch rejects an interval that is not positive,
+// and passes the errors of later renderings to onError
+func TestWatchErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "render")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.conf")
+
+	unavailable := errors.New("server unavailable")
+	api := &tsstest.SecretsAPIMock{}
+	api.SecretFunc = func(id int) (*server.Secret, error) {
+		if len(api.SecretCalls()) > 1 {
+			return nil, unavailable
+		}
+		return &server.Secret{ID: id, Fields: []server.SecretField{{Slug: "password", ItemValue: "s3cret"}}}, nil
+	}
+	tmpl, err := Parse(api, "test", `password={{ secret 42 "password" }}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, interval := range []time.Duration{0, -time.Second} {
+		if err := tmpl.Watch(path, interval, nil, nil, nil); err == nil {
+			t.Errorf("expected an error watching with the interval %s", interval)
+		}
+	}
+
+	errs := make(chan error, 10)
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- tmpl.Watch(path, 5*time.Millisecond, stop, nil, func(err error) {
+			errs <- err
+		})
+	}()
+	if err := <-errs; !errors.Is(err, unavailable) {
+		t.Errorf("expected the error of the failed rendering, found %v", err)
+	}
+	close(stop)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if contents, err := ioutil.ReadFile(path); err != nil || string(contents) != "password=s3cret" {
+		t.Errorf("expected the first rendering to be kept, found %q (%v)", contents, err)
+	}
+}
@@ -0,0 +1,199 @@
+// Package secretenv runs programs with secrets from Delinea Secret Server in
+// their environment, so that the secrets never have to be written to disk.
+//
+// Secrets are referred to by URIs of the form "tss://id/42#password", for the
+// password field of the secret with id 42, or "tss://path/Prod/DB#username",
+// for the username field of the secret named DB in the folder /Prod.
+package secretenv
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/DelineaXPM/tss-sdk-go/v3/server"
+)
+
+// Scheme is the URI scheme of references to secret fields
+const Scheme = "tss://"
+
+// Reference identifies a field of a secret, either by the id or by the folder
+// path and name of the secret
+type Reference struct {
+	ID    int
+	Path  string
+	Field string
+}
+
+// ParseReference parses a "tss://id/<id>#<field>" or "tss://path/<path>#<field>"
+// URI
+func ParseReference(uri string) (Reference, error) {
+	var ref Reference
+	if !strings.HasPrefix(uri, Scheme) {
+		return ref, fmt.Errorf("'%s' does not start with '%s'", uri, Scheme)
+	}
+	rest := uri[len(Scheme):]
+	i := strings.LastIndex(rest, "#")
+	if i < 0 || i == len(rest)-1 {
+		return ref, fmt.Errorf("'%s' does not name a field after '#'", uri)
+	}
+	ref.Field = rest[i+1:]
+	rest = rest[:i]
+
+	switch {
+	case strings.HasPrefix(rest, "id/"):
+		id, err := strconv.Atoi(rest[len("id/"):])
+		if err != nil || id <= 0 {
+			return ref, fmt.Errorf("'%s' does not contain a valid secret id", uri)
+		}
+		ref.ID = id
+	case strings.HasPrefix(rest, "path/") && len(rest) > len("path/"):
+		ref.Path = rest[len("path"):]
+	default:
+		return ref, fmt.Errorf("'%s' is neither a tss://id/ nor a tss://path/ reference", uri)
+	}
+	return ref, nil
+}
+
+// String returns the reference as a URI
+func (r Reference) String() string {
+	if r.Path != "" {
+		return fmt.Sprintf("%spath%s#%s", Scheme, r.Path, r.Field)
+	}
+	return fmt.Sprintf("%sid/%d#%s", Scheme, r.ID, r.Field)
+}
+
+// secretKey identifies the secret of the reference, ignoring the field
+func (r Reference) secretKey() string {
+	if r.Path != "" {
+		return "path:" + r.Path
+	}
+	return "id:" + strconv.Itoa(r.ID)
+}
+
+// Mapping maps an environment variable to a secret field
+type Mapping struct {
+	Name      string
+	Reference Reference
+}
+
+// ParseMapping parses a mapping of the form "NAME=tss://id/42#password"
+func ParseMapping(mapping string) (Mapping, error) {
+	i := strings.Index(mapping, "=")
+	if i < 1 {
+		return Mapping{}, fmt.Errorf("'%s' is not of the form NAME=%sid/<id>#<field>", mapping, Scheme)
+	}
+	ref, err := ParseReference(mapping[i+1:])
+	if err != nil {
+		return Mapping{}, err
+	}
+	return Mapping{Name: mapping[:i], Reference: ref}, nil
+}
+
+// Resolve gets the values of the mapped secret fields, keyed by environment
+// variable name. Each secret is fetched once, however many of its fields are
+// mapped.
+func Resolve(api server.SecretsAPI, mappings []Mapping) (map[string]string, error) {
+	secrets := make(map[string]*server.Secret)
+	values := make(map[string]string, len(mappings))
+
+	for _, mapping := range mappings {
+		ref := mapping.Reference
+		secret, found := secrets[ref.secretKey()]
+		if !found {
+			var err error
+			if ref.Path != "" {
+				secret, err = api.SecretByPath(ref.Path)
+			} else {
+				secret, err = api.Secret(ref.ID)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("resolving %s: %w", ref, err)
+			}
+			secrets[ref.secretKey()] = secret
+		}
+		value, found := secret.Field(ref.Field)
+		if !found {
+			return nil, fmt.Errorf("resolving %s: the secret '%s' has no field '%s'", ref, secret.Name, ref.Field)
+		}
+		values[mapping.Name] = value
+	}
+	return values, nil
+}
+
+// Environ returns base, in the "NAME=value" form of os.Environ, with the given
+// values added or replacing the variables of the same name
+func Environ(base []string, values map[string]string) []string {
+	env := make([]string, 0, len(base)+len(values))
+	for _, variable := range base {
+		name := variable
+		if i := strings.Index(variable, "="); i >= 0 {
+			name = variable[:i]
+		}
+		if _, replaced := values[name]; !replaced {
+			env = append(env, variable)
+		}
+	}
+	for name, value := range values {
+		env = append(env, name+"="+value)
+	}
+	return env
+}
+
+// forwardedSignals are the signals relayed to the child process
+var forwardedSignals = []os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT}
+
+// Run resolves the mappings, then runs the named program with the arguments,
+// the environment of the current process plus the mapped secrets, and the
+// standard input and outputs of the current process. Signals received while
+// the program runs are forwarded to it.
+//
+// Run returns the exit code of the program; if the program is terminated by a
+// signal, the exit code is 128 plus the signal number, as in a shell. The
+// error is only set if the program could not be run.
+func Run(api server.SecretsAPI, mappings []Mapping, name string, args ...string) (int, error) {
+	values, err := Resolve(api, mappings)
+	if err != nil {
+		return 0, err
+	}
+
+	cmd := exec.Command(name, args...)
+	cmd.Env = Environ(os.Environ(), values)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, forwardedSignals...)
+	defer signal.Stop(signals)
+
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+	log.Printf("[DEBUG] started '%s' with %d secret(s) in its environment", name, len(values))
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				cmd.Process.Signal(sig)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	err = cmd.Wait()
+	if _, exited := err.(*exec.ExitError); err != nil && !exited {
+		return 0, err
+	}
+	if status, ok := cmd.ProcessState.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal()), nil
+	}
+	return cmd.ProcessState.ExitCode(), nil
+}
@@ -0,0 +1,109 @@
+package secretenv
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/DelineaXPM/tss-sdk-go/v3/server"
+	"github.com/DelineaXPM/tss-sdk-go/v3/tsstest"
+)
+
+// fixtures holds the secret /Prod/DB, whose id is 42
+func fixtures() tsstest.Fixtures {
+	template := tsstest.PasswordTemplate()
+	template.ID = 6001
+	return tsstest.Fixtures{
+		Templates: []server.SecretTemplate{template},
+		Folders:   map[int]string{7: "/Prod"},
+		Secrets: []server.Secret{{ID: 42, Name: "DB", SecretTemplateID: template.ID, FolderID: 7,
+			Fields: []server.SecretField{{Slug: "username", ItemValue: "app"}, {Slug: "password", ItemValue: "s3cret"}}}},
+	}
+}
+
+// TestParseMapping tests the parsing of environment variable mappings
+func TestParseMapping(t *testing.T) {
+	valid := map[string]Mapping{
+		"DB_PASS=tss://id/42#password":        {"DB_PASS", Reference{ID: 42, Field: "password"}},
+		"DB_USER=tss://path/Prod/DB#username": {"DB_USER", Reference{Path: "/Prod/DB", Field: "username"}},
+	}
+	for input, expected := range valid {
+		mapping, err := ParseMapping(input)
+		if err != nil {
+			t.Errorf("parsing '%s': %s", input, err)
+		} else if mapping != expected {
+			t.Errorf("expected '%s' to parse as %+v, found %+v", input, expected, mapping)
+		} else if mapping.Reference.String() != input[len(mapping.Name)+1:] {
+			t.Errorf("expected the reference of '%s' to format as the original URI, found '%s'", input, mapping.Reference)
+		}
+	}
+	for _, input := range []string{"tss://id/42#password", "X=tss://id/42", "X=tss://id/abc#password", "X=tss://path/#password", "X=https://id/42#password"} {
+		if _, err := ParseMapping(input); err == nil {
+			t.Errorf("expected '%s' to be rejected", input)
+		}
+	}
+}
+
+// TestResolve tests that every secret is fetched once
+func TestResolve(t *testing.T) {
+	fake, _, api, err := tsstest.NewSecretsAPI(fixtures())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fake.Close()
+	mappings := []Mapping{
+		{"DB_USER", Reference{ID: 42, Field: "username"}},
+		{"DB_PASS", Reference{ID: 42, Field: "Password"}},
+		{"DB_PATH_PASS", Reference{Path: "/Prod/DB", Field: "password"}},
+	}
+	values, err := Resolve(api, mappings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values["DB_USER"] != "app" || values["DB_PASS"] != "s3cret" || values["DB_PATH_PASS"] != "s3cret" {
+		t.Errorf("unexpected values %v", values)
+	}
+	if lookups := len(api.SecretCalls()) + len(api.SecretByPathCalls()); lookups != 2 {
+		t.Errorf("expected 2 lookups, found %d", lookups)
+	}
+
+	if _, err := Resolve(api, []Mapping{{"X", Reference{ID: 42, Field: "missing"}}}); err == nil {
+		t.Error("expected a missing field to be an error")
+	}
+}
+
+// TestEnviron tests that mapped variables replace inherited ones
+func TestEnviron(t *testing.T) {
+	env := Environ([]string{"HOME=/root", "DB_PASS=old"}, map[string]string{"DB_PASS": "new"})
+	if len(env) != 2 || env[0] != "HOME=/root" || env[1] != "DB_PASS=new" {
+		t.Errorf("unexpected environment %v", env)
+	}
+}
+
+// TestRun tests that the program sees the secrets and its exit code is returned
+func TestRun(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+	fake, _, api, err := tsstest.NewSecretsAPI(fixtures())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fake.Close()
+	mappings := []Mapping{{"DB_PASS", Reference{ID: 42, Field: "password"}}}
+
+	code, err := Run(api, mappings, "sh", "-c", `test "$DB_PASS" = s3cret && exit 3`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != 3 {
+		t.Errorf("expected exit code 3, found %d", code)
+	}
+
+	code, err = Run(api, mappings, "sh", "-c", "kill -TERM $$")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != 128+15 {
+		t.Errorf("expected exit code 143, found %d", code)
+	}
+}
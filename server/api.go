@@ -0,0 +1,25 @@
+package server
+
+//go:generate moq -out ../tsstest/secrets_api_mock.go -pkg tsstest . SecretsAPI
+
+// SecretsAPI is the subset of the methods of Server that most code working
+// with secrets needs. Depend on it instead of *Server to substitute a mock,
+// such as tsstest.SecretsAPIMock, in tests.
+type SecretsAPI interface {
+	Secret(id int) (*Secret, error)
+	Secrets(searchText, field string) ([]Secret, error)
+	SecretByPath(secretPath string) (*Secret, error)
+	FolderSecrets(folderID int, includeSubfolders bool) ([]Secret, error)
+	CreateSecret(secret Secret) (*Secret, error)
+	UpdateSecret(secret Secret) (*Secret, error)
+	UpdateField(secretID int, slug, value string) error
+	DeleteSecret(id int) error
+	SecretTemplate(id int) (*SecretTemplate, error)
+	GeneratePassword(slug string, template *SecretTemplate) (string, error)
+}
+
+// Server implements SecretsAPI, both by value and by pointer
+var (
+	_ SecretsAPI = Server{}
+	_ SecretsAPI = (*Server)(nil)
+)
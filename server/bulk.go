@@ -0,0 +1,172 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// defaultBulkWorkers is the number of concurrent requests made by the bulk
+// operations when BulkOptions does not specify one
+const defaultBulkWorkers = 4
+
+// BulkOptions control how the bulk operations run
+type BulkOptions struct {
+	// Workers is the number of items processed concurrently
+	Workers int
+	// RequestsPerSecond limits the rate at which items are started; zero
+	// means no limit
+	RequestsPerSecond float64
+}
+
+// BulkResult is the outcome of a bulk operation for a single item. Index is
+// the position of the item in the input of the operation.
+type BulkResult struct {
+	Index, ID int
+	Secret    *Secret
+	Err       error
+}
+
+// BulkReport holds the result of every item of a bulk operation, in the order
+// of the input
+type BulkReport struct {
+	Results []BulkResult
+}
+
+// Failed returns the results of the items that failed
+func (r BulkReport) Failed() []BulkResult {
+	var failed []BulkResult
+	for _, result := range r.Results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// Succeeded returns the number of items that succeeded
+func (r BulkReport) Succeeded() int {
+	return len(r.Results) - len(r.Failed())
+}
+
+// BulkGet gets the secrets with the given ids
+func (s Server) BulkGet(ids []int, options BulkOptions) BulkReport {
+	return runBulk(len(ids), options, func(i int) BulkResult {
+		secret, err := s.Secret(ids[i])
+		return BulkResult{ID: ids[i], Secret: secret, Err: err}
+	})
+}
+
+// BulkCreate creates the given secrets, fetching each secret template they use
+// only once
+func (s Server) BulkCreate(secrets []Secret, options BulkOptions) BulkReport {
+	templates := newTemplateCache(s)
+	return runBulk(len(secrets), options, func(i int) BulkResult {
+		secret, err := s.writeSecret(secrets[i], "POST", "/", templates.get)
+		if err != nil {
+			return BulkResult{Err: err}
+		}
+		return BulkResult{ID: secret.ID, Secret: secret}
+	})
+}
+
+// BulkUpdate updates the given secrets, fetching each secret template they use
+// only once
+func (s Server) BulkUpdate(secrets []Secret, options BulkOptions) BulkReport {
+	templates := newTemplateCache(s)
+	return runBulk(len(secrets), options, func(i int) BulkResult {
+		secret, err := s.updateSecret(secrets[i], templates.get)
+		return BulkResult{ID: secrets[i].ID, Secret: secret, Err: err}
+	})
+}
+
+// BulkDelete deletes the secrets with the given ids
+func (s Server) BulkDelete(ids []int, options BulkOptions) BulkReport {
+	return runBulk(len(ids), options, func(i int) BulkResult {
+		return BulkResult{ID: ids[i], Err: s.DeleteSecret(ids[i])}
+	})
+}
+
+// runBulk calls operation for each of the count items on a pool of workers,
+// collecting the results in the order of the items
+func runBulk(count int, options BulkOptions, operation func(int) BulkResult) BulkReport {
+	workers := options.Workers
+	if workers <= 0 {
+		workers = defaultBulkWorkers
+	}
+
+	var throttle <-chan time.Time
+	if options.RequestsPerSecond > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / options.RequestsPerSecond))
+		defer ticker.Stop()
+		throttle = ticker.C
+	}
+
+	report := BulkReport{Results: make([]BulkResult, count)}
+	items := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range items {
+				result := operation(i)
+				result.Index = i
+				if result.Err != nil {
+					log.Printf("[ERROR] bulk operation failed for item %d: %s", i, result.Err)
+				}
+				report.Results[i] = result
+			}
+		}()
+	}
+	for i := 0; i < count; i++ {
+		if throttle != nil {
+			<-throttle
+		}
+		items <- i
+	}
+	close(items)
+	wg.Wait()
+
+	return report
+}
+
+// templateCache fetches each secret template at most once, for use by
+// operations that write many secrets
+type templateCache struct {
+	server    Server
+	mutex     sync.Mutex
+	templates map[int]*templateEntry
+}
+
+type templateEntry struct {
+	once     sync.Once
+	template *SecretTemplate
+	err      error
+}
+
+func newTemplateCache(s Server) *templateCache {
+	return &templateCache{server: s, templates: make(map[int]*templateEntry)}
+}
+
+// get returns the secret template with the given id, fetching it if it has not
+// been fetched yet
+func (c *templateCache) get(id int) (*SecretTemplate, error) {
+	c.mutex.Lock()
+	entry, found := c.templates[id]
+	if !found {
+		entry = new(templateEntry)
+		c.templates[id] = entry
+	}
+	c.mutex.Unlock()
+
+	entry.once.Do(func() {
+		entry.template, entry.err = c.server.SecretTemplate(id)
+		if entry.err != nil {
+			entry.err = fmt.Errorf("fetching the secret template with id '%d': %w", id, entry.err)
+		}
+	})
+	return entry.template, entry.err
+}
@@ -0,0 +1,149 @@
+package server_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/DelineaXPM/tss-sdk-go/v3/server"
+	"github.com/DelineaXPM/tss-sdk-go/v3/tsstest"
+)
+
+// templateRequests counts the requests for the secret template with the given
+// id among the requests the fake received
+func templateRequests(requests []string, id int) int {
+	count := 0
+	path := fmt.Sprintf("GET /api/v1/secret-templates/%d", id)
+	for _, request := range requests {
+		if request == path {
+			count++
+		}
+	}
+	return count
+}
+
+// TestBulkOperations tests creating, updating, getting and deleting secrets in
+// bulk against the fake, with a failing item in each operation
+func TestBulkOperations(t *testing.T) {
+	fake, template, tss := newFakeServer(t)
+	defer fake.Close()
+	options := server.BulkOptions{Workers: 3}
+
+	secrets := make([]server.Secret, 6)
+	for i := range secrets {
+		secrets[i] = server.Secret{Name: fmt.Sprintf("Bulk %d", i), SecretTemplateID: template.ID, FolderID: -1,
+			Fields: []server.SecretField{{Slug: "username", ItemValue: "app"}, {Slug: "password", ItemValue: "s3cret"}}}
+	}
+	// the template of the fourth secret does not exist
+	secrets[3].SecretTemplateID = 999
+
+	before := len(fake.Requests())
+	created := tss.BulkCreate(secrets, options)
+	requests := fake.Requests()[before:]
+	if len(created.Results) != len(secrets) || created.Succeeded() != 5 {
+		t.Fatalf("expected 5 of %d secrets to be created, found %+v", len(secrets), created)
+	}
+	if count := templateRequests(requests, template.ID); count != 1 {
+		t.Errorf("expected the template to be fetched once, found %d requests", count)
+	}
+	if count := templateRequests(requests, 999); count != 1 {
+		t.Errorf("expected the missing template to be fetched once, found %d requests", count)
+	}
+	ids := make([]int, 0, len(secrets))
+	for i, result := range created.Results {
+		if i == 3 {
+			if result.Err == nil || result.Secret != nil {
+				t.Errorf("expected the secret with a missing template to fail, found %+v", result)
+			}
+			continue
+		}
+		if result.Err != nil || result.Index != i || result.Secret == nil || result.Secret.Name != secrets[i].Name {
+			t.Errorf("expected result %d to be the secret %s, found %+v", i, secrets[i].Name, result)
+			continue
+		}
+		if stored, found := fake.Secret(result.ID); !found || stored.Name != secrets[i].Name {
+			t.Errorf("expected the secret %s to be stored, found %+v", secrets[i].Name, stored)
+		}
+		ids = append(ids, result.ID)
+	}
+
+	updates := make([]server.Secret, 0, len(ids)+1)
+	for _, result := range created.Results {
+		if result.Secret != nil {
+			secret := *result.Secret
+			secret.Name += " (updated)"
+			updates = append(updates, secret)
+		}
+	}
+	missing := updates[0]
+	missing.ID = 1000
+	updates = append(updates, missing)
+
+	before = len(fake.Requests())
+	updated := tss.BulkUpdate(updates, options)
+	if count := templateRequests(fake.Requests()[before:], template.ID); count != 1 {
+		t.Errorf("expected the template to be fetched once, found %d requests", count)
+	}
+	if failed := updated.Failed(); len(failed) != 1 || failed[0].Index != len(updates)-1 || failed[0].ID != 1000 {
+		t.Errorf("expected only the secret that does not exist to fail, found %+v", failed)
+	}
+	for _, id := range ids {
+		if stored, _ := fake.Secret(id); !strings.HasSuffix(stored.Name, " (updated)") {
+			t.Errorf("expected the secret with id %d to be renamed, found %q", id, stored.Name)
+		}
+	}
+
+	got := tss.BulkGet(append(ids, 1000), options)
+	for i, result := range got.Results {
+		switch {
+		case i == len(ids):
+			if result.Err == nil || result.ID != 1000 {
+				t.Errorf("expected the secret that does not exist to fail, found %+v", result)
+			}
+		case result.Err != nil || result.ID != ids[i] || result.Secret == nil || result.Secret.ID != ids[i]:
+			t.Errorf("expected result %d to be the secret with id %d, found %+v", i, ids[i], result)
+		}
+	}
+
+	deleted := tss.BulkDelete([]int{ids[0], 1000, ids[1]}, options)
+	if failed := deleted.Failed(); len(failed) != 1 || failed[0].Index != 1 || failed[0].ID != 1000 {
+		t.Errorf("expected only the secret that does not exist to fail, found %+v", failed)
+	}
+	for _, id := range ids[:2] {
+		if stored, _ := fake.Secret(id); stored.Active {
+			t.Errorf("expected the secret with id %d to be deleted", id)
+		}
+	}
+	if stored, _ := fake.Secret(ids[2]); !stored.Active {
+		t.Errorf("expected the secret with id %d to be kept", ids[2])
+	}
+}
+
+// TestBulkCreateTemplates tests that bulk creation fetches each of several
+// templates once
+func TestBulkCreateTemplates(t *testing.T) {
+	fake, template, tss := newFakeServer(t)
+	defer fake.Close()
+	sshTemplate := fake.AddTemplate(tsstest.SshKeyTemplate())
+
+	var secrets []server.Secret
+	for i := 0; i < 4; i++ {
+		secrets = append(secrets,
+			server.Secret{Name: fmt.Sprintf("DB %d", i), SecretTemplateID: template.ID, FolderID: -1,
+				Fields: []server.SecretField{{Slug: "username", ItemValue: "app"}, {Slug: "password", ItemValue: "s3cret"}}},
+			server.Secret{Name: fmt.Sprintf("Host %d", i), SecretTemplateID: sshTemplate.ID, FolderID: -1,
+				Fields: []server.SecretField{{Slug: "machine", ItemValue: "db.example.com"}, {Slug: "username", ItemValue: "root"}}})
+	}
+
+	before := len(fake.Requests())
+	report := tss.BulkCreate(secrets, server.BulkOptions{Workers: 4})
+	if failed := report.Failed(); len(failed) != 0 {
+		t.Fatalf("expected every secret to be created, found %+v", failed)
+	}
+	requests := fake.Requests()[before:]
+	for _, id := range []int{template.ID, sshTemplate.ID} {
+		if count := templateRequests(requests, id); count != 1 {
+			t.Errorf("expected the template with id %d to be fetched once, found %d requests", id, count)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+)
+
+// TestRunBulk tests that bulk operations report every item in input order and
+// keep going after a failure
+func TestRunBulk(t *testing.T) {
+	var running, maxRunning int32
+
+	report := runBulk(20, BulkOptions{Workers: 3}, func(i int) BulkResult {
+		current := atomic.AddInt32(&running, 1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if current <= max || atomic.CompareAndSwapInt32(&maxRunning, max, current) {
+				break
+			}
+		}
+		defer atomic.AddInt32(&running, -1)
+
+		if i%5 == 0 {
+			return BulkResult{ID: i, Err: fmt.Errorf("item %d failed", i)}
+		}
+		return BulkResult{ID: i}
+	})
+
+	if !validate("bulk result count", 20, len(report.Results), t) {
+		return
+	}
+	for i, result := range report.Results {
+		if result.Index != i || result.ID != i {
+			t.Errorf("expected result %d to be for item %d, found index %d and id %d", i, i, result.Index, result.ID)
+		}
+	}
+	validate("bulk failure count", 4, len(report.Failed()), t)
+	validate("bulk success count", 16, report.Succeeded(), t)
+	if maxRunning > 3 {
+		t.Errorf("expected at most 3 concurrent items, found %d", maxRunning)
+	}
+}
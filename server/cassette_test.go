@@ -0,0 +1,214 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DelineaXPM/tss-sdk-go/v3/tsstest/cassette"
+)
+
+// The integration tests can record their interactions with a live server to
+// cassettes in testdata/cassettes, and replay them without one. The mode is
+// chosen by TSS_CASSETTE_MODE:
+//
+//   - "record" runs the tests against the configured server and saves the
+//     sanitized interactions of each;
+//   - "replay" replays the saved interactions and fails tests that have none;
+//   - "live" runs the tests against the configured server without recording.
+//
+// If it is not set, tests with a cassette are replayed and the others run
+// live, or are skipped if no server is configured.
+const envCassetteMode = "TSS_CASSETTE_MODE"
+
+const (
+	cassetteModeRecord = "record"
+	cassetteModeReplay = "replay"
+	cassetteModeLive   = "live"
+)
+
+// cassetteURL replaces the URL of the recorded server in cassettes
+const cassetteURL = "https://tss.example.com"
+
+// cassetteEnvironment lists the environment variables read by the
+// integration tests, which are saved with each cassette. Those that are
+// sensitive are saved as placeholders.
+var cassetteEnvironment = []struct {
+	name      string
+	sensitive bool
+}{
+	{EnvUsername, true},
+	{EnvPassword, true},
+	{"TSS_PLATFORM_USERNAME", true},
+	{"TSS_PLATFORM_PASSWORD", true},
+	{"TSS_TEST_PASSWORD", true},
+	{"TSS_SECRET_ID", false},
+	{"TSS_SECRET_PATH", false},
+	{"TSS_TEMPLATE_ID", false},
+	{"TSS_FOLDER_ID", false},
+	{"TSS_SITE_ID", false},
+	{"TSS_SSH_KEY_TEMPLATE_ID", false},
+	{"TSS_SEARCH_FIELD", false},
+	{"TSS_SEARCH_TEXT", false},
+}
+
+// testServer is the Server of an integration test, with the environment the
+// test reads
+type testServer struct {
+	*Server
+
+	// environment holds the environment saved in the cassette being
+	// replayed, if any
+	environment map[string]string
+	// player replays the cassette, if any
+	player *cassette.Player
+}
+
+// getenv returns the value of a test environment variable, from the cassette
+// being replayed, if any
+func (s *testServer) getenv(name string) string {
+	if s.environment != nil {
+		return s.environment[name]
+	}
+	return os.Getenv(name)
+}
+
+// finish fails a test that passed without replaying all of its cassette
+func (s *testServer) finish(t *testing.T) {
+	if s.player == nil || t.Failed() {
+		return
+	}
+	if err := s.player.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+// placeholder returns the value that replaces a sensitive environment variable
+// in cassettes
+func placeholder(name string) string {
+	return "redacted-" + name
+}
+
+// cassettePath returns the path of the cassette of the test
+func cassettePath(t *testing.T) string {
+	return filepath.Join("testdata", "cassettes", strings.Replace(t.Name(), "/", "_", -1)+".json")
+}
+
+// cassetteMode returns the mode in which the test runs
+func cassetteMode(t *testing.T) (string, error) {
+	switch mode := os.Getenv(envCassetteMode); mode {
+	case cassetteModeRecord, cassetteModeReplay, cassetteModeLive:
+		return mode, nil
+	case "":
+		if _, err := os.Stat(cassettePath(t)); err == nil {
+			return cassetteModeReplay, nil
+		}
+		return cassetteModeLive, nil
+	default:
+		return "", fmt.Errorf("%s must be '%s', '%s' or '%s', not '%s'", envCassetteMode,
+			cassetteModeRecord, cassetteModeReplay, cassetteModeLive, mode)
+	}
+}
+
+// initTestServer returns a Server for the test: in replay mode, one that
+// replays the cassette of the test, with the environment saved in it, and
+// otherwise one for the configuration returned by configure, which records to
+// the cassette in record mode. urlVariable is the environment variable that
+// holds the server URL. A test that has no cassette is skipped unless a server
+// is configured or the mode is set. The test should defer finish.
+func initTestServer(t *testing.T, urlVariable string, configure func() (*Configuration, error)) (*testServer, error) {
+	mode, err := cassetteMode(t)
+	if err != nil {
+		return nil, err
+	}
+
+	test := new(testServer)
+	var config *Configuration
+	if mode == cassetteModeReplay {
+		recorded, err := cassette.Load(cassettePath(t))
+		if err != nil {
+			return nil, err
+		}
+		test.environment = recorded.Environment
+		if test.environment == nil {
+			test.environment = make(map[string]string)
+		}
+		test.player = cassette.NewPlayer(recorded)
+		usernameVariable, passwordVariable := credentialVariables(urlVariable)
+		config = &Configuration{
+			ServerURL: test.environment[urlVariable],
+			Credentials: UserCredential{
+				Username: test.environment[usernameVariable],
+				Password: test.environment[passwordVariable],
+			},
+			Transport: test.player,
+		}
+	} else if config, err = configure(); err != nil {
+		return nil, err
+	} else if config.ServerURL == "" && config.Tenant == "" && os.Getenv(envCassetteMode) == "" {
+		t.Skipf("%s has no cassette and no server is configured", t.Name())
+	}
+
+	if test.Server, err = New(*config); err != nil {
+		return nil, err
+	}
+	if mode == cassetteModeRecord {
+		recorder := recordTo(t, test.Configuration, urlVariable)
+		recorder.Transport = test.Transport
+		test.Transport = recorder
+	}
+	if mode != cassetteModeLive {
+		// start every cassette with the request for a token
+		test.clearTokenCache()
+	}
+	return test, nil
+}
+
+// credentialVariables returns the environment variables that hold the
+// credentials for the server whose URL is in urlVariable
+func credentialVariables(urlVariable string) (string, string) {
+	if urlVariable == EnvServerURL {
+		return EnvUsername, EnvPassword
+	}
+	return "TSS_PLATFORM_USERNAME", "TSS_PLATFORM_PASSWORD"
+}
+
+// recordTo returns a Recorder for the cassette of the test, which replaces
+// the URL and credentials of the configuration, as completed by New, and the sensitive environment
+// variables, with placeholders
+func recordTo(t *testing.T, config Configuration, urlVariable string) *cassette.Recorder {
+	sanitizer := cassette.NewSanitizer()
+	environment := map[string]string{urlVariable: cassetteURL}
+
+	baseURL := config.ServerURL
+	if baseURL == "" {
+		baseURL = fmt.Sprintf(cloudBaseURLTemplate, config.Tenant, config.TLD)
+	}
+	sanitizer.Replace(strings.TrimRight(baseURL, "/"), cassetteURL)
+
+	usernameVariable, passwordVariable := credentialVariables(urlVariable)
+	sanitizer.Replace(config.Credentials.Password, placeholder(passwordVariable))
+	sanitizer.Replace(config.Credentials.Username, placeholder(usernameVariable))
+	environment[usernameVariable] = placeholder(usernameVariable)
+	environment[passwordVariable] = placeholder(passwordVariable)
+
+	for _, variable := range cassetteEnvironment {
+		value := os.Getenv(variable.name)
+		if value == "" {
+			continue
+		}
+		if variable.sensitive {
+			sanitizer.Replace(value, placeholder(variable.name))
+			value = placeholder(variable.name)
+		}
+		if _, set := environment[variable.name]; !set {
+			environment[variable.name] = value
+		}
+	}
+
+	recorder := cassette.NewRecorder(cassettePath(t), sanitizer)
+	recorder.SetEnvironment(environment)
+	return recorder
+}
@@ -0,0 +1,375 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/DelineaXPM/tss-sdk-go/v3/internal/yaml"
+)
+
+// The environment variables read by ConfigurationFromEnv
+const (
+	// EnvServerURL is the URL of Secret Server or Platform
+	EnvServerURL = "TSS_SERVER_URL"
+	// EnvTenant is the Secret Server Cloud tenant
+	EnvTenant = "TSS_TENANT"
+	// EnvTLD is the top-level domain of Secret Server Cloud, "com" by default
+	EnvTLD = "TSS_TLD"
+	// EnvUsername is the user to authenticate as
+	EnvUsername = "TSS_USERNAME"
+	// EnvPassword is the password of the user
+	EnvPassword = "TSS_PASSWORD"
+	// EnvDomain is the domain of the user
+	EnvDomain = "TSS_DOMAIN"
+	// EnvToken is an access token to use instead of a username and password
+	EnvToken = "TSS_TOKEN"
+	// EnvCABundle is a PEM file of certificate authorities to trust in
+	// addition to the system's
+	EnvCABundle = "TSS_CA_BUNDLE"
+	// EnvConfigFile is the profile file, ~/.config/tss/config.yaml by default
+	EnvConfigFile = "TSS_CONFIG_FILE"
+	// EnvProfile is the name of the profile to use from the profile file
+	EnvProfile = "TSS_PROFILE"
+)
+
+// defaultProfileName is used when neither TSS_PROFILE nor the profile file
+// names a profile
+const defaultProfileName = "default"
+
+// Profile is a named connection profile from a profile file. Either
+// ServerURL or Tenant is set. The password and token may be given directly,
+// or read from an environment variable or a file.
+type Profile struct {
+	ServerURL, Tenant, TLD, Domain, Username string
+	Password, PasswordEnv, PasswordFile      string
+	Token, TokenEnv, TokenFile               string
+	// CABundle is a PEM file of certificate authorities to trust in
+	// addition to the system's
+	CABundle string
+}
+
+// Profiles is the contents of a profile file:
+//
+//	default_profile: prod
+//	profiles:
+//	  prod:
+//	    server_url: https://delinea.mycompany.com/SecretServer
+//	    domain: CORP
+//	    username: app
+//	    password_env: PROD_TSS_PASSWORD
+//	    ca_bundle: /etc/ssl/mycompany-ca.pem
+//	  cloud:
+//	    tenant: mycompany
+//	    tld: eu
+//	    token_file: ~/.tss/token
+type Profiles struct {
+	DefaultProfile string
+	Profiles       map[string]Profile
+}
+
+// profileKeys maps the keys of a profile in the profile file to its fields
+var profileKeys = map[string]func(*Profile) *string{
+	"server_url":    func(p *Profile) *string { return &p.ServerURL },
+	"tenant":        func(p *Profile) *string { return &p.Tenant },
+	"tld":           func(p *Profile) *string { return &p.TLD },
+	"domain":        func(p *Profile) *string { return &p.Domain },
+	"username":      func(p *Profile) *string { return &p.Username },
+	"password":      func(p *Profile) *string { return &p.Password },
+	"password_env":  func(p *Profile) *string { return &p.PasswordEnv },
+	"password_file": func(p *Profile) *string { return &p.PasswordFile },
+	"token":         func(p *Profile) *string { return &p.Token },
+	"token_env":     func(p *Profile) *string { return &p.TokenEnv },
+	"token_file":    func(p *Profile) *string { return &p.TokenFile },
+	"ca_bundle":     func(p *Profile) *string { return &p.CABundle },
+}
+
+// DefaultProfilePath returns the path of the profile file used when
+// TSS_CONFIG_FILE is not set: config.yaml in the tss directory of
+// $XDG_CONFIG_HOME, or of ~/.config
+func DefaultProfilePath() (string, error) {
+	if configHome := os.Getenv("XDG_CONFIG_HOME"); configHome != "" {
+		return filepath.Join(configHome, "tss", "config.yaml"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "tss", "config.yaml"), nil
+}
+
+// LoadProfiles reads the profile file at path
+func LoadProfiles(path string) (*Profiles, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	profiles, err := ParseProfiles(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return profiles, nil
+}
+
+// ParseProfiles parses the contents of a profile file
+func ParseProfiles(data []byte) (*Profiles, error) {
+	document, err := yaml.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	profiles := &Profiles{Profiles: make(map[string]Profile)}
+
+	for key, value := range document {
+		switch key {
+		case "default_profile":
+			name, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("default_profile must be a profile name")
+			}
+			profiles.DefaultProfile = name
+		case "profiles":
+			named, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("profiles must be a mapping of names to profiles")
+			}
+			for name, value := range named {
+				settings, ok := value.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("the profile '%s' must be a mapping", name)
+				}
+				var profile Profile
+				for setting, value := range settings {
+					field, known := profileKeys[setting]
+					if !known {
+						return nil, fmt.Errorf("the profile '%s' has an unknown setting '%s'", name, setting)
+					}
+					if *field(&profile), ok = value.(string); !ok {
+						return nil, fmt.Errorf("the setting '%s' of the profile '%s' must be a value", setting, name)
+					}
+				}
+				profiles.Profiles[name] = profile
+			}
+		default:
+			return nil, fmt.Errorf("unknown setting '%s'", key)
+		}
+	}
+	if profiles.DefaultProfile != "" {
+		if _, found := profiles.Profiles[profiles.DefaultProfile]; !found {
+			return nil, fmt.Errorf("the default profile '%s' is not defined", profiles.DefaultProfile)
+		}
+	}
+	return profiles, nil
+}
+
+// Profile returns the profile with the given name
+func (p Profiles) Profile(name string) (Profile, error) {
+	profile, found := p.Profiles[name]
+	if !found {
+		names := make([]string, 0, len(p.Profiles))
+		for name := range p.Profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return Profile{}, fmt.Errorf("there is no profile named '%s'; the profiles are: %s", name, strings.Join(names, ", "))
+	}
+	return profile, nil
+}
+
+// Configuration returns the configuration described by the profile, reading
+// the credentials and the CA bundle from wherever the profile says
+func (p Profile) Configuration() (*Configuration, error) {
+	return p.configuration(os.Getenv)
+}
+
+func (p Profile) configuration(getenv func(string) string) (*Configuration, error) {
+	config := &Configuration{
+		ServerURL: p.ServerURL,
+		Tenant:    p.Tenant,
+		TLD:       p.TLD,
+		Credentials: UserCredential{
+			Domain:   p.Domain,
+			Username: p.Username,
+		},
+	}
+	var err error
+	if config.Credentials.Password, err = credential("password", p.Password, p.PasswordEnv, p.PasswordFile, getenv); err != nil {
+		return nil, err
+	}
+	if config.Credentials.Token, err = credential("token", p.Token, p.TokenEnv, p.TokenFile, getenv); err != nil {
+		return nil, err
+	}
+	if p.CABundle != "" {
+		if config.TLSClientConfig, err = tlsConfigWithCABundle(p.CABundle); err != nil {
+			return nil, err
+		}
+	}
+	return config, nil
+}
+
+// credential returns the value given directly, or read from the named
+// environment variable or file, only one of which may be set
+func credential(kind, value, envName, path string, getenv func(string) string) (string, error) {
+	sources := 0
+	for _, source := range []string{value, envName, path} {
+		if source != "" {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return "", fmt.Errorf("only one of %[1]s, %[1]s_env and %[1]s_file may be set", kind)
+	}
+
+	switch {
+	case envName != "":
+		value = getenv(envName)
+		if value == "" {
+			return "", fmt.Errorf("the environment variable %s, which should hold the %s, is not set", envName, kind)
+		}
+	case path != "":
+		data, err := ioutil.ReadFile(expandHome(path))
+		if err != nil {
+			return "", fmt.Errorf("reading the %s: %w", kind, err)
+		}
+		value = strings.TrimRight(string(data), "\r\n")
+	}
+	return value, nil
+}
+
+// expandHome replaces a leading ~/ in the path with the home directory
+func expandHome(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[2:])
+		}
+	}
+	return path
+}
+
+// tlsConfigWithCABundle returns a TLS configuration that trusts the
+// certificate authorities in the PEM file as well as the system's
+func tlsConfigWithCABundle(path string) (*tls.Config, error) {
+	pem, err := ioutil.ReadFile(expandHome(path))
+	if err != nil {
+		return nil, fmt.Errorf("reading the CA bundle: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("the CA bundle %s contains no PEM certificates", path)
+	}
+	return &tls.Config{RootCAs: pool}, nil
+}
+
+// ConfigurationFromEnv returns a configuration from the profile file and the
+// TSS_* environment variables, which take precedence. The profile is the one
+// named by TSS_PROFILE, or else by default_profile in the profile file, or
+// else the one named "default", if the file has one. The profile file is
+// read from TSS_CONFIG_FILE, or DefaultProfilePath; it need not exist unless
+// either variable is set.
+//
+// Setting TSS_SERVER_URL replaces the tenant from the profile and setting
+// TSS_TENANT replaces the server URL. The result is validated by New.
+func ConfigurationFromEnv() (*Configuration, error) {
+	return ConfigurationFromEnvFunc(os.Getenv)
+}
+
+// ConfigurationFromEnvFunc is ConfigurationFromEnv with the environment read
+// through getenv, which allows callers to supply or override variables
+func ConfigurationFromEnvFunc(getenv func(string) string) (*Configuration, error) {
+	config, err := configurationFromProfileFile(getenv)
+	if err != nil {
+		return nil, err
+	}
+
+	override := func(target *string, name string) {
+		if value := getenv(name); value != "" {
+			*target = value
+		}
+	}
+	override(&config.ServerURL, EnvServerURL)
+	override(&config.Tenant, EnvTenant)
+	override(&config.TLD, EnvTLD)
+	override(&config.Credentials.Username, EnvUsername)
+	override(&config.Credentials.Password, EnvPassword)
+	override(&config.Credentials.Domain, EnvDomain)
+	override(&config.Credentials.Token, EnvToken)
+
+	// a URL from the environment replaces a tenant from the profile, and
+	// vice versa, since New rejects a configuration with both
+	if getenv(EnvServerURL) != "" && getenv(EnvTenant) == "" {
+		config.Tenant = ""
+	} else if getenv(EnvTenant) != "" && getenv(EnvServerURL) == "" {
+		config.ServerURL = ""
+	}
+
+	if caBundle := getenv(EnvCABundle); caBundle != "" {
+		if config.TLSClientConfig, err = tlsConfigWithCABundle(caBundle); err != nil {
+			return nil, err
+		}
+	}
+	return config, nil
+}
+
+// configurationFromProfileFile returns the configuration of the selected
+// profile, or an empty one if there is no profile file and none was asked for
+func configurationFromProfileFile(getenv func(string) string) (*Configuration, error) {
+	path, name := getenv(EnvConfigFile), getenv(EnvProfile)
+	explicit := path != "" || name != ""
+	if path == "" {
+		var err error
+		if path, err = DefaultProfilePath(); err != nil {
+			if explicit {
+				return nil, err
+			}
+			return new(Configuration), nil
+		}
+	}
+
+	profiles, err := LoadProfiles(path)
+	if os.IsNotExist(err) && !explicit {
+		return new(Configuration), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case name != "":
+	case profiles.DefaultProfile != "":
+		name = profiles.DefaultProfile
+	default:
+		if _, found := profiles.Profiles[defaultProfileName]; !found {
+			return new(Configuration), nil
+		}
+		name = defaultProfileName
+	}
+	profile, err := profiles.Profile(name)
+	if err != nil {
+		return nil, err
+	}
+	return profile.configuration(getenv)
+}
+
+// validate checks that the configuration identifies a server and a way to
+// authenticate to it
+func (c Configuration) validate() error {
+	if c.ServerURL == "" && c.Tenant == "" || c.ServerURL != "" && c.Tenant != "" {
+		return fmt.Errorf("either ServerURL of Secret Server/Platform or Tenant of Secret Server Cloud must be set")
+	}
+	if c.ServerURL != "" {
+		if u, err := url.Parse(c.ServerURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("ServerURL '%s' is not an http or https URL", c.ServerURL)
+		}
+	}
+	if c.Credentials.Token == "" && (c.Credentials.Username == "" || c.Credentials.Password == "") {
+		return fmt.Errorf("either Credentials.Token or Credentials.Username and Credentials.Password must be set")
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testProfiles = `# connection profiles
+default_profile: prod
+profiles:
+  prod:
+    server_url: https://delinea.example.com/SecretServer  # on premises
+    domain: CORP
+    username: app
+    password_env: PROD_PASSWORD
+  cloud:
+    tenant: "example"
+    tld: eu
+    username: 'o''brien'
+    token_file: %s
+`
+
+// TestConfigurationFromEnv tests that the environment takes precedence over
+// the selected profile
+func TestConfigurationFromEnv(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tss")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tokenFile := filepath.Join(dir, "token")
+	configFile := filepath.Join(dir, "config.yaml")
+	if err = ioutil.WriteFile(tokenFile, []byte("cloud-token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(configFile, []byte(fmt.Sprintf(testProfiles, tokenFile)), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	env := map[string]string{EnvConfigFile: configFile, "PROD_PASSWORD": "prod-password"}
+	getenv := func(name string) string { return env[name] }
+
+	config, err := ConfigurationFromEnvFunc(getenv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.ServerURL != "https://delinea.example.com/SecretServer" || config.Credentials.Domain != "CORP" || config.Credentials.Password != "prod-password" {
+		t.Errorf("expected the default profile, found %+v", config)
+	}
+	if _, err = New(*config); err != nil {
+		t.Errorf("expected the default profile to be valid: %s", err)
+	}
+
+	env[EnvProfile] = "cloud"
+	env[EnvUsername] = "env-user"
+	config, err = ConfigurationFromEnvFunc(getenv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Tenant != "example" || config.TLD != "eu" || config.Credentials.Token != "cloud-token" || config.Credentials.Username != "env-user" {
+		t.Errorf("expected the cloud profile with the username from the environment, found %+v", config)
+	}
+
+	env[EnvServerURL] = "https://other.example.com"
+	if config, err = ConfigurationFromEnvFunc(getenv); err != nil || config.Tenant != "" {
+		t.Errorf("expected the URL from the environment to replace the tenant, found %+v", config)
+	}
+
+	env[EnvProfile] = "missing"
+	if _, err = ConfigurationFromEnvFunc(getenv); err == nil {
+		t.Error("expected a missing profile to be an error")
+	}
+
+	delete(env, EnvProfile)
+	delete(env, "PROD_PASSWORD")
+	if _, err = ConfigurationFromEnvFunc(getenv); err == nil {
+		t.Error("expected an unset password_env variable to be an error")
+	}
+}
+
+// TestNewValidation tests that New rejects incomplete configurations
+func TestNewValidation(t *testing.T) {
+	credentials := UserCredential{Username: "user", Password: "password"}
+	for _, config := range []Configuration{
+		{Credentials: credentials},
+		{Credentials: credentials, ServerURL: "https://example.com", Tenant: "example"},
+		{Credentials: credentials, ServerURL: "example.com"},
+		{ServerURL: "https://example.com"},
+		{Credentials: UserCredential{Username: "user"}, Tenant: "example"},
+	} {
+		if _, err := New(config); err == nil {
+			t.Errorf("expected %+v to be rejected", config)
+		}
+	}
+	if _, err := New(Configuration{Credentials: UserCredential{Token: "token"}, Tenant: "example"}); err != nil {
+		t.Errorf("expected a tenant and token to be accepted: %s", err)
+	}
+}
+
+// TestNewTLSClientConfig tests that the TLS configuration applies to the new
+// Server alone, not to http.DefaultTransport
+func TestNewTLSClientConfig(t *testing.T) {
+	tlsConfig := &tls.Config{ServerName: "delinea.example.com"}
+
+	tss, err := New(Configuration{Credentials: UserCredential{Token: "token"}, Tenant: "example", TLSClientConfig: tlsConfig})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transport, ok := tss.Transport.(*http.Transport); !ok || transport.TLSClientConfig != tlsConfig {
+		t.Errorf("expected a transport with the TLS configuration, found %#v", tss.Transport)
+	}
+	if http.DefaultTransport.(*http.Transport).TLSClientConfig == tlsConfig {
+		t.Error("expected http.DefaultTransport to keep its own TLS configuration")
+	}
+}
@@ -0,0 +1,25 @@
+package server
+
+import (
+	"time"
+)
+
+// serverTimeLayouts are the layouts in which Secret Server renders dates; some
+// endpoints omit the time zone, in which case the time is taken to be UTC
+var serverTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// parseServerTime parses a date returned by Secret Server, returning the zero
+// time if the value is empty or not in a recognized layout
+func parseServerTime(value string) time.Time {
+	for _, layout := range serverTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
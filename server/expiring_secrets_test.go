@@ -0,0 +1,116 @@
+package server_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DelineaXPM/tss-sdk-go/v3/server"
+)
+
+// TestExpiringSecrets tests finding the secrets that expire soon against the
+// fake, with and without sorting them by expiration
+func TestExpiringSecrets(t *testing.T) {
+	fake, template, tss := newFakeServer(t)
+	defer fake.Close()
+	later := time.Now().Add(100 * 24 * time.Hour).UTC().Format(time.RFC3339)
+	for i := 0; i < 600; i++ {
+		secret := server.Secret{Name: fmt.Sprintf("later-%d", i), SecretTemplateID: template.ID, FolderID: -1}
+		if i%2 == 0 {
+			secret.ExpirationDate = later
+		}
+		if _, err := fake.AddSecret(secret); err != nil {
+			t.Fatal(err)
+		}
+	}
+	soon := addPasswordSecret(t, fake, template, "soon")
+	expired := addPasswordSecret(t, fake, template, "expired")
+	if err := tss.SetSecretExpiration(soon.ID, time.Now().Add(5*24*time.Hour+time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := tss.SetSecretExpiration(expired.ID, time.Now().Add(-49*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	expiring, err := tss.ExpiringSecrets(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expiring) != 2 || expiring[0].ID != expired.ID || expiring[0].DaysUntilExpiration != -2 ||
+		expiring[1].ID != soon.ID || expiring[1].DaysUntilExpiration != 5 {
+		t.Errorf("expected the expired secret, then the one expiring soon, found %+v", expiring)
+	}
+
+	// a server that ignores the sort order returns the secrets by id, with
+	// those past the cutoff first
+	tss.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		query := req.URL.Query()
+		query.Del("sortBy[0].name")
+		query.Del("sortBy[0].direction")
+		req.URL.RawQuery = query.Encode()
+		return http.DefaultTransport.RoundTrip(req)
+	})
+	unsorted, err := tss.ExpiringSecrets(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unsorted) != 2 || unsorted[0] != expiring[0] || unsorted[1] != expiring[1] {
+		t.Errorf("expected the same secrets from a server that does not sort them, found %+v", unsorted)
+	}
+	tss.Transport = nil
+
+	if err = tss.ClearSecretExpiration(soon.ID); err != nil {
+		t.Fatal(err)
+	}
+	if expiring, err = tss.ExpiringSecrets(10); err != nil || len(expiring) != 1 {
+		t.Errorf("expected only the expired secret, found %+v (%v)", expiring, err)
+	}
+
+	err = tss.SetSecretExpiration(1000, time.Now())
+	if err == nil || !strings.Contains(err.Error(), "Access Denied") {
+		t.Errorf("expected an error setting the expiration of a secret that does not exist, found %v", err)
+	}
+}
+
+// TestSecretPolicies tests looking up secret policies and applying them to
+// secrets against the fake
+func TestSecretPolicies(t *testing.T) {
+	fake, template, tss := newFakeServer(t)
+	defer fake.Close()
+	quarterly := fake.AddSecretPolicy("Rotate Quarterly", "Changes the password every 90 days")
+	fake.AddSecretPolicy("Rotate Quarterly (Legacy)", "")
+	secret := addPasswordSecret(t, fake, template, "DB")
+
+	policy, err := tss.SecretPolicy(quarterly.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *policy != quarterly {
+		t.Errorf("expected %+v, found %+v", quarterly, *policy)
+	}
+	if _, err = tss.SecretPolicy(quarterly.ID + 100); err == nil {
+		t.Error("expected an error getting a secret policy that does not exist")
+	}
+
+	// the search matches names that contain the given one, of which only the
+	// exact match is taken
+	policy, found, err := tss.SecretPolicyByName("rotate quarterly")
+	if err != nil || !found || policy.ID != quarterly.ID {
+		t.Errorf("expected the policy named 'Rotate Quarterly', found %+v, %t (%v)", policy, found, err)
+	}
+	if _, found, err = tss.SecretPolicyByName("Rotate"); err != nil || found {
+		t.Errorf("expected no policy named 'Rotate', found %t (%v)", found, err)
+	}
+
+	if err = tss.ApplySecretPolicy(secret.ID, "Rotate Quarterly"); err != nil {
+		t.Fatal(err)
+	}
+	if stored, _ := fake.Secret(secret.ID); stored.SecretPolicyID != quarterly.ID {
+		t.Errorf("expected the policy to be applied, found the policy id %d", stored.SecretPolicyID)
+	}
+	if err = tss.ApplySecretPolicy(secret.ID, "Nonexistent"); err == nil {
+		t.Error("expected an error applying a secret policy that does not exist")
+	}
+}
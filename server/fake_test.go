@@ -0,0 +1,43 @@
+package server_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/DelineaXPM/tss-sdk-go/v3/server"
+	"github.com/DelineaXPM/tss-sdk-go/v3/tsstest"
+)
+
+// The tests in package server_test run the client against the fake in
+// tsstest, which imports package server
+
+// newFakeServer starts a fake Secret Server with the Password template, and
+// returns it with the template and a client for it. The caller should Close
+// the fake when finished.
+func newFakeServer(t *testing.T) (*tsstest.Server, server.SecretTemplate, *server.Server) {
+	fake := tsstest.NewServer()
+	template := fake.AddTemplate(tsstest.PasswordTemplate())
+	tss, err := server.New(fake.Configuration())
+	if err != nil {
+		fake.Close()
+		t.Fatal(err)
+	}
+	return fake, template, tss
+}
+
+// addPasswordSecret adds a secret with the Password template to the fake
+func addPasswordSecret(t *testing.T, fake *tsstest.Server, template server.SecretTemplate, name string) server.Secret {
+	secret, err := fake.AddSecret(server.Secret{Name: name, SecretTemplateID: template.ID, FolderID: -1,
+		Fields: []server.SecretField{{Slug: "username", ItemValue: "app"}, {Slug: "password", ItemValue: "s3cret"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return secret
+}
+
+// roundTripFunc is an http.RoundTripper that calls itself
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// groupResource is the HTTP URL path component for the groups resource
+const groupResource = "groups"
+
+// Group represents a Secret Server group
+type Group struct {
+	ID, DomainID int `json:",omitempty"`
+	Name         string
+	Enabled      bool
+}
+
+// GroupMember is a user that belongs to a group
+type GroupMember struct {
+	GroupID, UserID       int
+	UserName, DisplayName string
+}
+
+// Groups provides the group administration operations of Secret Server
+type Groups struct {
+	server Server
+}
+
+// Groups returns the group administration operations, authenticated as this
+// Server
+func (s Server) Groups() Groups {
+	return Groups{server: s}
+}
+
+// Get gets the group with the given id
+func (g Groups) Get(id int) (*Group, error) {
+	return g.request("GET", strconv.Itoa(id), nil)
+}
+
+// Search gets the groups whose names contain the given text
+func (g Groups) Search(searchText string) ([]Group, error) {
+	groups := make([]Group, 0)
+	query := fmt.Sprintf("filter.searchText=%s&filter.includeInactive=true", url.QueryEscape(searchText))
+
+	err := g.server.listAll(groupResource, "", query, func(data json.RawMessage) error {
+		var page []Group
+		if err := json.Unmarshal(data, &page); err != nil {
+			return err
+		}
+		groups = append(groups, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// ByName gets the group with the given name, and a boolean indicating whether
+// there is such a group
+func (g Groups) ByName(name string) (*Group, bool, error) {
+	groups, err := g.Search(name)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, group := range groups {
+		if strings.EqualFold(group.Name, name) {
+			return &group, true, nil
+		}
+	}
+	log.Printf("[DEBUG] no group named '%s'", name)
+	return nil, false, nil
+}
+
+// Create creates the given group
+func (g Groups) Create(group Group) (*Group, error) {
+	return g.request("POST", "/", group)
+}
+
+// Members gets the users that belong to the group with the given id
+func (g Groups) Members(id int) ([]GroupMember, error) {
+	members := make([]GroupMember, 0)
+
+	err := g.server.listAll(groupResource, fmt.Sprintf("%d/users", id), "", func(data json.RawMessage) error {
+		var page []GroupMember
+		if err := json.Unmarshal(data, &page); err != nil {
+			return err
+		}
+		members = append(members, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// AddMember adds the user with the given id to the group with the given id
+func (g Groups) AddMember(groupID, userID int) error {
+	input := struct{ UserID int }{UserID: userID}
+	_, err := g.server.accessResource("POST", groupResource, fmt.Sprintf("%d/users", groupID), input)
+	return err
+}
+
+// RemoveMember removes the user with the given id from the group with the
+// given id
+func (g Groups) RemoveMember(groupID, userID int) error {
+	_, err := g.server.accessResource("DELETE", groupResource, fmt.Sprintf("%d/users/%d", groupID, userID), nil)
+	return err
+}
+
+func (g Groups) request(method, path string, input interface{}) (*Group, error) {
+	group := new(Group)
+
+	if data, err := g.server.accessResource(method, groupResource, path, input); err == nil {
+		if err = json.Unmarshal(data, group); err != nil {
+			log.Printf("[ERROR] error parsing response from /%s/%s: %q", groupResource, path, data)
+			return nil, err
+		}
+	} else {
+		return nil, err
+	}
+	return group, nil
+}
@@ -0,0 +1,50 @@
+package server_test
+
+import (
+	"testing"
+
+	"github.com/DelineaXPM/tss-sdk-go/v3/server"
+)
+
+// TestGroups tests group administration against the fake
+func TestGroups(t *testing.T) {
+	fake, _, tss := newFakeServer(t)
+	defer fake.Close()
+
+	user, err := tss.Users().Create(server.User{UserName: "jdoe", Password: "Passw0rd!", Enabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	groups := tss.Groups()
+	created, err := groups.Create(server.Group{Name: "DBAs", Enabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if group, found, err := groups.ByName("dbas"); err != nil || !found || group.ID != created.ID {
+		t.Errorf("expected to find the group by name, found %+v (%v)", group, err)
+	}
+
+	if err = groups.AddMember(created.ID, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	members, err := groups.Members(created.ID)
+	if err != nil || len(members) != 1 || members[0].UserName != "jdoe" {
+		t.Errorf("expected the added member, found %+v (%v)", members, err)
+	}
+	if err = groups.RemoveMember(created.ID, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if members, err = groups.Members(created.ID); err != nil || len(members) != 0 {
+		t.Errorf("expected no members, found %+v (%v)", members, err)
+	}
+
+	if err = groups.RemoveMember(created.ID, user.ID); err == nil {
+		t.Error("expected an error removing a user that is not a member")
+	}
+	if err = groups.AddMember(created.ID, 1000); err == nil {
+		t.Error("expected an error adding a user that does not exist")
+	}
+	if _, err = groups.Create(server.Group{Name: "dbas"}); err == nil {
+		t.Error("expected an error creating a group with a name in use")
+	}
+}
@@ -8,6 +8,13 @@ import (
 
 const errorBodyLength = 255
 
+// do sends the request with the configured Transport, first waiting for the
+// rate limit, if one is configured
+func (s Server) do(req *http.Request) (*http.Response, error) {
+	s.limiter.wait(req)
+	return (&http.Client{Transport: s.Transport}).Do(req)
+}
+
 // handleResponse processes the response according to the HTTP status
 func handleResponse(res *http.Response, err error) ([]byte, *http.Response, error) {
 	if err != nil { // fall-through if there was an underlying err
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+// defaultPageSize is the number of records requested per page when the caller
+// does not specify one
+const defaultPageSize = 30
+
+// Paging selects a page of records from a list endpoint
+type Paging struct {
+	Skip, Take int
+}
+
+// query renders the paging as URL query parameters
+func (p Paging) query() string {
+	if p.Take <= 0 {
+		p.Take = defaultPageSize
+	}
+	return fmt.Sprintf("skip=%d&take=%d", p.Skip, p.Take)
+}
+
+// listAll requests every page of the list at the given path of the given
+// resource, filtered by the given query, and passes the records of each page
+// to the given function
+func (s Server) listAll(resourceName, listPath, query string, records func(json.RawMessage) error) error {
+	return s.listPages(resourceName, listPath, query, defaultPageSize, records)
+}
+
+// listPages behaves like listAll, requesting pages of the given size
+func (s Server) listPages(resourceName, listPath, query string, pageSize int, records func(json.RawMessage) error) error {
+	paging := Paging{Take: pageSize}
+	for {
+		page := struct {
+			Records json.RawMessage
+			HasNext bool
+			Take    int
+		}{}
+		path := fmt.Sprintf("%s?%s", listPath, paging.query())
+		if query != "" {
+			path = fmt.Sprintf("%s&%s", path, query)
+		}
+
+		if data, err := s.accessResource("GET", resourceName, path, nil); err == nil {
+			if err = json.Unmarshal(data, &page); err != nil {
+				log.Printf("[ERROR] error parsing response from /%s/%s: %q", resourceName, path, data)
+				return err
+			}
+		} else {
+			return err
+		}
+
+		if err := records(page.Records); err != nil {
+			log.Printf("[ERROR] error parsing records from /%s/%s: %s", resourceName, path, err)
+			return err
+		}
+		if !page.HasNext || page.Take == 0 {
+			return nil
+		}
+		paging.Skip += page.Take
+	}
+}
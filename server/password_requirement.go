@@ -0,0 +1,144 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"unicode/utf8"
+)
+
+// PasswordRequirement holds the rules that the value of a password field must
+// satisfy
+type PasswordRequirement struct {
+	ID                   int
+	Name, Description    string
+	MinLength, MaxLength int
+	// AllowedCharacters are the only characters a password may contain; if
+	// empty, any character is allowed
+	AllowedCharacters string
+	Rules             []CharacterSetRule
+}
+
+// CharacterSetRule requires a password to contain at least MinRequired of
+// the given Characters
+type CharacterSetRule struct {
+	Name, Characters string
+	MinRequired      int
+}
+
+// PasswordRequirements gets the password requirements of the secret template
+// field with the given id
+func (s Server) PasswordRequirements(templateFieldID int) (*PasswordRequirement, error) {
+	response := struct {
+		ID                                   int
+		Name, Description                    string
+		MinPasswordLength, MaxPasswordLength int
+		AllowedCharacterSet                  struct{ Characters string }
+		CharacterSetRules                    []struct {
+			CharacterSet struct{ Name, Characters string }
+			MinRequired  int
+		}
+	}{}
+	path := fmt.Sprintf("fields/%d/password-requirements", templateFieldID)
+
+	if data, err := s.accessResource("GET", templateResource, path, nil); err == nil {
+		if err = json.Unmarshal(data, &response); err != nil {
+			log.Printf("[ERROR] error parsing response from /%s/%s: %q", templateResource, path, data)
+			return nil, err
+		}
+	} else {
+		return nil, err
+	}
+
+	requirement := &PasswordRequirement{
+		ID:                response.ID,
+		Name:              response.Name,
+		Description:       response.Description,
+		MinLength:         response.MinPasswordLength,
+		MaxLength:         response.MaxPasswordLength,
+		AllowedCharacters: response.AllowedCharacterSet.Characters,
+		Rules:             make([]CharacterSetRule, len(response.CharacterSetRules)),
+	}
+	for i, rule := range response.CharacterSetRules {
+		requirement.Rules[i] = CharacterSetRule{
+			Name:        rule.CharacterSet.Name,
+			Characters:  rule.CharacterSet.Characters,
+			MinRequired: rule.MinRequired,
+		}
+	}
+	return requirement, nil
+}
+
+// ValidatePassword checks the candidate password against the requirements of
+// the password field identified by the given slug on the given template,
+// without sending the password to the server. It returns a *ValidationError
+// listing every violated rule, or nil if the password is acceptable.
+func (s Server) ValidatePassword(template *SecretTemplate, slug, candidate string) error {
+	if template == nil {
+		return fmt.Errorf("a secret template is required to validate the password of field '%s'", slug)
+	}
+	field, found := template.GetField(slug)
+	if !found {
+		return fmt.Errorf("field name '%s' is not defined on the secret template with id '%d'", slug, template.ID)
+	}
+	if !field.IsPassword {
+		return fmt.Errorf("field '%s' of the secret template with id '%d' is not a password field", slug, template.ID)
+	}
+
+	requirement, err := s.PasswordRequirements(field.SecretTemplateFieldID)
+	if err != nil {
+		return err
+	}
+	return requirement.Validate(candidate)
+}
+
+// Validate checks the candidate password against the requirement, returning
+// a *ValidationError listing every violated rule, or nil if there are none
+func (r PasswordRequirement) Validate(candidate string) error {
+	var problems []string
+	length := utf8.RuneCountInString(candidate)
+
+	if length < r.MinLength {
+		problems = append(problems, fmt.Sprintf("the password must be at least %d characters long", r.MinLength))
+	}
+	if r.MaxLength > 0 && length > r.MaxLength {
+		problems = append(problems, fmt.Sprintf("the password must be at most %d characters long", r.MaxLength))
+	}
+	if r.AllowedCharacters != "" {
+		var disallowed []string
+		for _, c := range candidate {
+			if !strings.ContainsRune(r.AllowedCharacters, c) && !containsString(disallowed, string(c)) {
+				disallowed = append(disallowed, string(c))
+			}
+		}
+		if len(disallowed) > 0 {
+			problems = append(problems, fmt.Sprintf("the password contains characters that are not allowed: %s", strings.Join(disallowed, " ")))
+		}
+	}
+	for _, rule := range r.Rules {
+		count := 0
+		for _, c := range candidate {
+			if strings.ContainsRune(rule.Characters, c) {
+				count++
+			}
+		}
+		if count < rule.MinRequired {
+			problems = append(problems, fmt.Sprintf("the password must contain at least %d %s characters", rule.MinRequired, rule.Name))
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
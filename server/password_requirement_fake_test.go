@@ -0,0 +1,68 @@
+package server_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DelineaXPM/tss-sdk-go/v3/server"
+)
+
+// TestValidatePassword tests fetching password requirements from the fake
+// and validating passwords against them
+func TestValidatePassword(t *testing.T) {
+	fake, template, tss := newFakeServer(t)
+	defer fake.Close()
+	field, _ := template.GetField("password")
+	requirement := server.PasswordRequirement{
+		ID:                7,
+		Name:              "Strong",
+		MinLength:         8,
+		MaxLength:         12,
+		AllowedCharacters: "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789!.",
+		Rules: []server.CharacterSetRule{
+			{Name: "upper case", Characters: "ABCDEFGHIJKLMNOPQRSTUVWXYZ", MinRequired: 1},
+			{Name: "numeric", Characters: "0123456789", MinRequired: 2},
+		},
+	}
+	fake.SetPasswordRequirement(field.SecretTemplateFieldID, requirement)
+
+	fetched, err := tss.PasswordRequirements(field.SecretTemplateFieldID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fetched.Name != "Strong" || fetched.MinLength != 8 || fetched.MaxLength != 12 ||
+		fetched.AllowedCharacters != requirement.AllowedCharacters || len(fetched.Rules) != 2 || fetched.Rules[1] != requirement.Rules[1] {
+		t.Errorf("expected the requirement that was set, found %+v", fetched)
+	}
+
+	if err = tss.ValidatePassword(&template, "password", "Passw0rd.9"); err != nil {
+		t.Errorf("expected 'Passw0rd.9' to be valid, found '%v'", err)
+	}
+	err = tss.ValidatePassword(&template, "password", "pass word")
+	if validationError, ok := err.(*server.ValidationError); !ok || len(validationError.Problems) != 3 {
+		t.Errorf("expected a *ValidationError with 3 problems, found '%v'", err)
+	}
+
+	for _, test := range []struct{ slug, problem string }{
+		{"username", "not a password field"},
+		{"missing", "is not defined"},
+	} {
+		if err = tss.ValidatePassword(&template, test.slug, "Passw0rd.9"); err == nil || !strings.Contains(err.Error(), test.problem) {
+			t.Errorf("expected an error containing %q for the field '%s', found '%v'", test.problem, test.slug, err)
+		}
+	}
+	username, _ := template.GetField("username")
+	if _, err = tss.PasswordRequirements(username.SecretTemplateFieldID); err == nil {
+		t.Error("expected an error getting the requirements of a field that is not a password field")
+	}
+
+	// the other password fields have the default requirement
+	other := fake.AddTemplate(server.SecretTemplate{Name: "Other", Fields: []server.SecretTemplateField{
+		{Name: "Password", FieldSlugName: "password", IsPassword: true}}})
+	if err = tss.ValidatePassword(&other, "password", "short"); err == nil {
+		t.Error("expected a short password to fail the default requirement")
+	}
+	if err = tss.ValidatePassword(&other, "password", "long enough password"); err != nil {
+		t.Errorf("expected a long password to pass the default requirement, found '%v'", err)
+	}
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"testing"
+)
+
+// TestPasswordRequirementValidate tests that every violated password rule is
+// reported
+func TestPasswordRequirementValidate(t *testing.T) {
+	requirement := PasswordRequirement{
+		MinLength:         8,
+		MaxLength:         12,
+		AllowedCharacters: "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789!.",
+		Rules: []CharacterSetRule{
+			{Name: "upper case", Characters: "ABCDEFGHIJKLMNOPQRSTUVWXYZ", MinRequired: 1},
+			{Name: "numeric", Characters: "0123456789", MinRequired: 2},
+		},
+	}
+
+	if err := requirement.Validate("Passw0rd.9"); err != nil {
+		t.Errorf("expected 'Passw0rd.9' to be valid, found '%v'", err)
+	}
+
+	err := requirement.Validate("pass word")
+	validationError, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatalf("expected a *ValidationError, found '%v'", err)
+	}
+	// disallowed space, upper case, numeric
+	validate("password problem count", 3, len(validationError.Problems), t)
+
+	err = requirement.Validate("Passw0rd.1234567")
+	if validationError, ok = err.(*ValidationError); !ok {
+		t.Fatalf("expected a *ValidationError, found '%v'", err)
+	}
+	validate("password problem count", 1, len(validationError.Problems), t)
+}
+
+// TestValidatePasswordWithoutTemplate tests that a nil template is reported
+// rather than dereferenced
+func TestValidatePasswordWithoutTemplate(t *testing.T) {
+	if err := (Server{}).ValidatePassword(nil, "password", "Passw0rd.9"); err == nil {
+		t.Error("expected an error validating a password without a template")
+	}
+}
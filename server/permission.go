@@ -0,0 +1,186 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+)
+
+// secretPermissionResource and folderPermissionResource are the HTTP URL path
+// components for the secret and folder permissions resources
+const (
+	secretPermissionResource = "secret-permissions"
+	folderPermissionResource = "folder-permissions"
+)
+
+// Secret access roles that can be granted on a secret, or on the secrets in a
+// folder
+const (
+	SecretRoleList  = "List"
+	SecretRoleView  = "View"
+	SecretRoleEdit  = "Edit"
+	SecretRoleOwner = "Owner"
+)
+
+// Folder access roles that can be granted on a folder
+const (
+	FolderRoleView      = "View"
+	FolderRoleEdit      = "Edit"
+	FolderRoleAddSecret = "Add Secret"
+	FolderRoleOwner     = "Owner"
+)
+
+// SecretPermission grants a user or a group a role on a secret. Exactly one
+// of UserID and GroupID should be set when adding a permission.
+type SecretPermission struct {
+	ID, SecretID, UserID, GroupID   int    `json:",omitempty"`
+	UserName, GroupName, DomainName string `json:",omitempty"`
+	SecretAccessRoleName            string
+}
+
+// FolderPermission grants a user or a group a role on a folder, and a role on
+// the secrets in it. Exactly one of UserID and GroupID should be set when
+// adding a permission.
+type FolderPermission struct {
+	ID, FolderID, UserID, GroupID              int    `json:",omitempty"`
+	UserName, GroupName, DomainName            string `json:",omitempty"`
+	FolderAccessRoleName, SecretAccessRoleName string
+}
+
+// SecretPermissions gets the permissions granted on the secret with the given id
+func (s Server) SecretPermissions(secretID int) ([]SecretPermission, error) {
+	permissions := make([]SecretPermission, 0)
+	query := fmt.Sprintf("filter.secretId=%d", secretID)
+
+	err := s.listAll(secretPermissionResource, "", query, func(data json.RawMessage) error {
+		var page []SecretPermission
+		if err := json.Unmarshal(data, &page); err != nil {
+			return err
+		}
+		permissions = append(permissions, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
+// SecretPermission gets the secret permission with the given id
+func (s Server) SecretPermission(id int) (*SecretPermission, error) {
+	return s.sendSecretPermission("GET", strconv.Itoa(id), nil)
+}
+
+// AddSecretPermission grants the given permission on a secret
+func (s Server) AddSecretPermission(permission SecretPermission) (*SecretPermission, error) {
+	permission.ID = 0
+	return s.sendSecretPermission("POST", "/", permission)
+}
+
+// UpdateSecretPermission changes the role granted by the secret permission
+// with the given id. The server requires the whole permission, so it is read
+// first.
+func (s Server) UpdateSecretPermission(id int, role string) (*SecretPermission, error) {
+	permission, err := s.SecretPermission(id)
+	if err != nil {
+		return nil, err
+	}
+	permission.SecretAccessRoleName = role
+	return s.sendSecretPermission("PUT", strconv.Itoa(id), permission)
+}
+
+// RemoveSecretPermission revokes the secret permission with the given id
+func (s Server) RemoveSecretPermission(id int) error {
+	_, err := s.accessResource("DELETE", secretPermissionResource, strconv.Itoa(id), nil)
+	return err
+}
+
+// SetInheritPermissions sets whether the secret with the given id inherits
+// the permissions of its folder, in addition to its own
+func (s Server) SetInheritPermissions(secretID int, enabled bool) error {
+	return s.patchGeneral(secretID, fieldMods{EnableInheritPermissions: &dirtyValue{Dirty: true, Value: enabled}})
+}
+
+// FolderPermissions gets the permissions granted on the folder with the given id
+func (s Server) FolderPermissions(folderID int) ([]FolderPermission, error) {
+	permissions := make([]FolderPermission, 0)
+	query := fmt.Sprintf("filter.folderId=%d", folderID)
+
+	err := s.listAll(folderPermissionResource, "", query, func(data json.RawMessage) error {
+		var page []FolderPermission
+		if err := json.Unmarshal(data, &page); err != nil {
+			return err
+		}
+		permissions = append(permissions, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
+// FolderPermission gets the folder permission with the given id
+func (s Server) FolderPermission(id int) (*FolderPermission, error) {
+	return s.sendFolderPermission("GET", strconv.Itoa(id), nil)
+}
+
+// AddFolderPermission grants the given permission on a folder
+func (s Server) AddFolderPermission(permission FolderPermission) (*FolderPermission, error) {
+	permission.ID = 0
+	return s.sendFolderPermission("POST", "/", permission)
+}
+
+// UpdateFolderPermission changes the roles granted by the folder permission
+// with the given id. The server requires the whole permission, so it is read
+// first.
+func (s Server) UpdateFolderPermission(id int, folderRole, secretRole string) (*FolderPermission, error) {
+	permission, err := s.FolderPermission(id)
+	if err != nil {
+		return nil, err
+	}
+	permission.FolderAccessRoleName = folderRole
+	permission.SecretAccessRoleName = secretRole
+	return s.sendFolderPermission("PUT", strconv.Itoa(id), permission)
+}
+
+// RemoveFolderPermission revokes the folder permission with the given id
+func (s Server) RemoveFolderPermission(id int) error {
+	_, err := s.accessResource("DELETE", folderPermissionResource, strconv.Itoa(id), nil)
+	return err
+}
+
+// sendSecretPermission sends the request with the given input, which may be
+// nil, to the secret permissions resource and returns the permission in the
+// response
+func (s Server) sendSecretPermission(method, path string, input interface{}) (*SecretPermission, error) {
+	permission := new(SecretPermission)
+
+	if data, err := s.accessResource(method, secretPermissionResource, path, input); err == nil {
+		if err = json.Unmarshal(data, permission); err != nil {
+			log.Printf("[ERROR] error parsing response from /%s: %q", secretPermissionResource, data)
+			return nil, err
+		}
+	} else {
+		return nil, err
+	}
+	return permission, nil
+}
+
+// sendFolderPermission sends the request with the given input, which may be
+// nil, to the folder permissions resource and returns the permission in the
+// response
+func (s Server) sendFolderPermission(method, path string, input interface{}) (*FolderPermission, error) {
+	permission := new(FolderPermission)
+
+	if data, err := s.accessResource(method, folderPermissionResource, path, input); err == nil {
+		if err = json.Unmarshal(data, permission); err != nil {
+			log.Printf("[ERROR] error parsing response from /%s: %q", folderPermissionResource, data)
+			return nil, err
+		}
+	} else {
+		return nil, err
+	}
+	return permission, nil
+}
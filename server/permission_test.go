@@ -0,0 +1,102 @@
+package server_test
+
+import (
+	"testing"
+
+	"github.com/DelineaXPM/tss-sdk-go/v3/server"
+)
+
+// TestSecretPermissions tests granting, listing, changing and revoking
+// secret permissions against the fake
+func TestSecretPermissions(t *testing.T) {
+	fake, template, tss := newFakeServer(t)
+	defer fake.Close()
+	secret := addPasswordSecret(t, fake, template, "Shared")
+
+	added, err := tss.AddSecretPermission(server.SecretPermission{
+		SecretID: secret.ID, GroupID: 12, SecretAccessRoleName: server.SecretRoleView})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added.ID == 0 || added.SecretID != secret.ID || added.GroupID != 12 {
+		t.Errorf("expected the added permission, found %+v", added)
+	}
+
+	// the whole permission is sent, as the server requires its secret and group
+	updated, err := tss.UpdateSecretPermission(added.ID, server.SecretRoleOwner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.SecretAccessRoleName != server.SecretRoleOwner || updated.SecretID != secret.ID || updated.GroupID != 12 {
+		t.Errorf("expected the role of the permission to change, found %+v", updated)
+	}
+
+	permissions, err := tss.SecretPermissions(secret.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(permissions) != 1 || permissions[0].SecretAccessRoleName != server.SecretRoleOwner {
+		t.Errorf("expected the updated permission, found %+v", permissions)
+	}
+
+	if err = tss.SetInheritPermissions(secret.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	if stored, _ := fake.Secret(secret.ID); !stored.EnableInheritPermissions {
+		t.Error("expected the secret to inherit permissions")
+	}
+
+	if err = tss.RemoveSecretPermission(added.ID); err != nil {
+		t.Fatal(err)
+	}
+	if permissions, err = tss.SecretPermissions(secret.ID); err != nil || len(permissions) != 0 {
+		t.Errorf("expected no permissions, found %+v (%v)", permissions, err)
+	}
+
+	if _, err = tss.AddSecretPermission(server.SecretPermission{
+		SecretID: secret.ID, UserID: 3, GroupID: 12, SecretAccessRoleName: server.SecretRoleView}); err == nil {
+		t.Error("expected an error granting a permission to both a user and a group")
+	}
+	if _, err = tss.UpdateSecretPermission(added.ID, server.SecretRoleEdit); err == nil {
+		t.Error("expected an error updating a permission that was removed")
+	}
+}
+
+// TestFolderPermissions tests granting, listing, changing and revoking
+// folder permissions against the fake
+func TestFolderPermissions(t *testing.T) {
+	fake, _, tss := newFakeServer(t)
+	defer fake.Close()
+	fake.AddFolder(7, "/Prod")
+
+	added, err := tss.AddFolderPermission(server.FolderPermission{FolderID: 7, UserID: 3,
+		FolderAccessRoleName: server.FolderRoleView, SecretAccessRoleName: server.SecretRoleList})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := tss.UpdateFolderPermission(added.ID, server.FolderRoleAddSecret, server.SecretRoleEdit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.FolderAccessRoleName != server.FolderRoleAddSecret || updated.SecretAccessRoleName != server.SecretRoleEdit ||
+		updated.FolderID != 7 || updated.UserID != 3 {
+		t.Errorf("expected the roles of the permission to change, found %+v", updated)
+	}
+
+	permissions, err := tss.FolderPermissions(7)
+	if err != nil || len(permissions) != 1 || permissions[0].ID != added.ID {
+		t.Errorf("expected the permission on the folder, found %+v (%v)", permissions, err)
+	}
+
+	if err = tss.RemoveFolderPermission(added.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err = tss.RemoveFolderPermission(added.ID); err == nil {
+		t.Error("expected an error removing a permission twice")
+	}
+	if _, err = tss.AddFolderPermission(server.FolderPermission{FolderID: 8, UserID: 3,
+		FolderAccessRoleName: server.FolderRoleView, SecretAccessRoleName: server.SecretRoleList}); err == nil {
+		t.Error("expected an error granting a permission on a folder that does not exist")
+	}
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RateLimit configures the client-side limits on the rate of requests to the
+// server. Reads (GET and HEAD requests) and writes (all other requests,
+// including those for access tokens) have separate budgets. A rate of zero
+// leaves that kind of request unlimited.
+type RateLimit struct {
+	ReadsPerSecond, WritesPerSecond float64
+	// ReadBurst and WriteBurst are the number of requests that can be made at
+	// once after a period of inactivity; they default to one
+	ReadBurst, WriteBurst int
+}
+
+// rateLimiter holds the token buckets for reads and writes. It is shared by
+// all copies of a Server.
+type rateLimiter struct {
+	read, write *tokenBucket
+}
+
+func newRateLimiter(limit *RateLimit) *rateLimiter {
+	if limit == nil {
+		return nil
+	}
+	return &rateLimiter{
+		read:  newTokenBucket(limit.ReadsPerSecond, limit.ReadBurst),
+		write: newTokenBucket(limit.WritesPerSecond, limit.WriteBurst),
+	}
+}
+
+// wait blocks until the budget for the given request allows it to be sent
+func (l *rateLimiter) wait(req *http.Request) {
+	if l == nil {
+		return
+	}
+	switch req.Method {
+	case "GET", "HEAD":
+		l.read.wait()
+	default:
+		l.write.wait()
+	}
+}
+
+// tokenBucket allows rate requests per second on average, and up to burst
+// requests at once
+type tokenBucket struct {
+	mutex         sync.Mutex
+	rate, burst   float64
+	tokens        float64
+	lastRefreshed time.Time
+	// now and sleep are time.Now and time.Sleep, except in tests
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if rate <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{
+		rate:          rate,
+		burst:         float64(burst),
+		tokens:        float64(burst),
+		lastRefreshed: time.Now(),
+		now:           time.Now,
+		sleep:         time.Sleep,
+	}
+}
+
+// wait blocks until a token is available and takes it
+func (b *tokenBucket) wait() {
+	if b == nil {
+		return
+	}
+	for {
+		b.mutex.Lock()
+		now := b.now()
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.lastRefreshed).Seconds()*b.rate)
+		b.lastRefreshed = now
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mutex.Unlock()
+			return
+		}
+		delay := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		b.mutex.Unlock()
+
+		log.Printf("[DEBUG] rate limit reached, waiting %s", delay)
+		b.sleep(delay)
+	}
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+// fakeClock stands in for the clock of a token bucket, advancing only when
+// the bucket sleeps
+type fakeClock struct {
+	now   time.Time
+	slept time.Duration
+}
+
+func (c *fakeClock) install(b *tokenBucket) {
+	c.now = b.lastRefreshed
+	b.now = func() time.Time { return c.now }
+	b.sleep = func(d time.Duration) {
+		c.now = c.now.Add(d)
+		c.slept += d
+	}
+}
+
+// TestRateLimiter tests that reads and writes are limited independently
+func TestRateLimiter(t *testing.T) {
+	limiter := newRateLimiter(&RateLimit{ReadsPerSecond: 20, ReadBurst: 2})
+	clock := new(fakeClock)
+	clock.install(limiter.read)
+	read, _ := http.NewRequest("GET", "https://example.com/", nil)
+	write, _ := http.NewRequest("POST", "https://example.com/", nil)
+
+	for i := 0; i < 6; i++ {
+		limiter.wait(read)
+	}
+	// two requests use the burst, the other four wait 50ms each; the
+	// tolerance allows for rounding in the token arithmetic
+	if expected := 200 * time.Millisecond; clock.slept < expected-time.Millisecond || clock.slept > expected+time.Millisecond {
+		t.Errorf("expected six reads to wait %s, waited %s", expected, clock.slept)
+	}
+
+	// after a pause, the burst is available again
+	clock.now, clock.slept = clock.now.Add(time.Second), 0
+	limiter.wait(read)
+	limiter.wait(read)
+	if clock.slept != 0 {
+		t.Errorf("expected the burst to be available after a pause, waited %s", clock.slept)
+	}
+
+	if limiter.write != nil {
+		t.Error("expected writes to be unlimited")
+	}
+	for i := 0; i < 100; i++ {
+		limiter.wait(write)
+	}
+
+	var unlimited *rateLimiter
+	unlimited.wait(read)
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"strings"
+)
+
+// roleResource is the HTTP URL path component for the roles resource
+const roleResource = "roles"
+
+// Role represents a Secret Server role, a named set of permissions that can be
+// assigned to users and groups
+type Role struct {
+	ID      int
+	Name    string
+	Enabled bool
+}
+
+// Roles provides the role administration operations of Secret Server
+type Roles struct {
+	server Server
+}
+
+// Roles returns the role administration operations, authenticated as this
+// Server
+func (s Server) Roles() Roles {
+	return Roles{server: s}
+}
+
+// List gets every role
+func (r Roles) List() ([]Role, error) {
+	roles := make([]Role, 0)
+
+	err := r.server.listAll(roleResource, "", "", func(data json.RawMessage) error {
+		var page []Role
+		if err := json.Unmarshal(data, &page); err != nil {
+			return err
+		}
+		roles = append(roles, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// ByName gets the role with the given name, and a boolean indicating whether
+// there is such a role
+func (r Roles) ByName(name string) (*Role, bool, error) {
+	roles, err := r.List()
+	if err != nil {
+		return nil, false, err
+	}
+	for _, role := range roles {
+		if strings.EqualFold(role.Name, name) {
+			return &role, true, nil
+		}
+	}
+	log.Printf("[DEBUG] no role named '%s'", name)
+	return nil, false, nil
+}
@@ -0,0 +1,31 @@
+package server_test
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestRoles tests listing roles, over several pages, against the fake
+func TestRoles(t *testing.T) {
+	fake, _, tss := newFakeServer(t)
+	defer fake.Close()
+	for i := 0; i < 35; i++ {
+		fake.AddRole(fmt.Sprintf("Role %d", i))
+	}
+
+	roles, err := tss.Roles().List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roles) != 35 || roles[34].Name != "Role 34" {
+		t.Errorf("expected every role, found %d", len(roles))
+	}
+
+	role, found, err := tss.Roles().ByName("role 12")
+	if err != nil || !found || role.Name != "Role 12" {
+		t.Errorf("expected to find the role by name, found %+v (%v)", role, err)
+	}
+	if _, found, err = tss.Roles().ByName("Role"); found || err != nil {
+		t.Errorf("expected a role lookup to match exactly, found %v (%v)", found, err)
+	}
+}
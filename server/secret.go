@@ -4,13 +4,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"strconv"
 	"net/url"
+	"strconv"
 )
 
 // resource is the HTTP URL path component for the secrets resource
 const resource = "secrets"
 
+// eraseRequestResource is the HTTP URL path component for the secret erase
+// requests resource
+const eraseRequestResource = "secret-erase-requests"
+
 // Secret represents a secret from Delinea Secret Server
 type Secret struct {
 	Name                                                                       string
@@ -21,6 +25,7 @@ type Secret struct {
 	AutoChangeEnabled, CheckOutChangePasswordEnabled, DelayIndexing            bool
 	EnableInheritPermissions, EnableInheritSecretPolicy, ProxyEnabled          bool
 	RequiresComment, SessionRecordingEnabled, WebLauncherRequiresIncognitoMode bool
+	ExpirationDate                                                             string        `json:",omitempty"`
 	Fields                                                                     []SecretField `json:"Items"`
 	SshKeyArgs                                                                 *SshKeyArgs   `json:",omitempty"`
 }
@@ -47,11 +52,40 @@ type SshKeyArgs struct {
 	GeneratePassphrase, GenerateSshKeys bool
 }
 
+// LookupOptions control which secrets are returned by the lookup methods
+type LookupOptions struct {
+	// IncludeInactive includes secrets that have been deleted (deactivated)
+	IncludeInactive bool
+}
+
 // Secret gets the secret with id from the Secret Server of the given tenant
 func (s Server) Secret(id int) (*Secret, error) {
+	return s.SecretWithOptions(id, LookupOptions{})
+}
+
+// SecretWithOptions gets the secret with id, as restricted by the given options
+func (s Server) SecretWithOptions(id int, options LookupOptions) (*Secret, error) {
+	secret, err := s.secretWithoutFiles(id, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.downloadFiles(secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// secretWithoutFiles gets the secret with id, leaving the (dummy) ItemValue of
+// its file fields in place of the contents of the file attachments
+func (s Server) secretWithoutFiles(id int, options LookupOptions) (*Secret, error) {
 	secret := new(Secret)
+	path := strconv.Itoa(id)
+	if options.IncludeInactive {
+		path = path + "?includeInactive=true"
+	}
 
-	if data, err := s.accessResource("GET", resource, strconv.Itoa(id), nil); err == nil {
+	if data, err := s.accessResource("GET", resource, path, nil); err == nil {
 		if err = json.Unmarshal(data, secret); err != nil {
 			log.Printf("[ERROR] error parsing response from /%s/%d: %q", resource, id, data)
 			return nil, err
@@ -59,28 +93,20 @@ func (s Server) Secret(id int) (*Secret, error) {
 	} else {
 		return nil, err
 	}
-
-	// automatically download file attachments and substitute them for the
-	// (dummy) ItemValue, so as to make the process transparent to the caller
-	for index, element := range secret.Fields {
-		if element.IsFile && element.FileAttachmentID != 0 && element.Filename != "" {
-			path := fmt.Sprintf("%d/fields/%s", id, element.Slug)
-
-			if data, err := s.accessResource("GET", resource, path, nil); err == nil {
-				secret.Fields[index].ItemValue = string(data)
-			} else {
-				return nil, err
-			}
-		}
-	}
-
 	return secret, nil
 }
 
-// Secret gets the secret with id from the Secret Server of the given tenant
+// Secrets gets the secrets that match the search text in the given field, or
+// in any field if field is empty
 func (s Server) Secrets(searchText, field string) ([]Secret, error) {
+	return s.SecretsWithOptions(searchText, field, LookupOptions{})
+}
+
+// SecretsWithOptions gets the secrets that match the search text in the given
+// field, as restricted by the given options
+func (s Server) SecretsWithOptions(searchText, field string, options LookupOptions) ([]Secret, error) {
 	searchResult := new(SearchResult)
-	if data, err := s.searchResources(resource, searchText, field); err == nil {
+	if data, err := s.searchResources(resource, searchText, field, options.IncludeInactive); err == nil {
 		if err = json.Unmarshal(data, searchResult); err != nil {
 			log.Printf("[ERROR] error parsing response from /%s/%s: %q", resource, searchText, data)
 			return nil, err
@@ -93,7 +119,7 @@ func (s Server) Secrets(searchText, field string) ([]Secret, error) {
 	secrets := make([]Secret, len(searchRecords))
 	for i, record := range searchRecords {
 		//secrets returned in search results are not fully populated
-		secret, err := s.Secret(record.ID)
+		secret, err := s.SecretWithOptions(record.ID, options)
 		if err != nil {
 			return nil, err
 		}
@@ -103,11 +129,52 @@ func (s Server) Secrets(searchText, field string) ([]Secret, error) {
 	return secrets, nil
 }
 
+// FolderSecrets gets the active secrets in the folder with the given id and,
+// if includeSubfolders is set, in its subfolders
+func (s Server) FolderSecrets(folderID int, includeSubfolders bool) ([]Secret, error) {
+	ids := make([]int, 0)
+	query := fmt.Sprintf("filter.folderId=%d&filter.includeSubFolders=%t", folderID, includeSubfolders)
+
+	err := s.listAll(resource, "", query, func(data json.RawMessage) error {
+		var page []struct{ ID int }
+		if err := json.Unmarshal(data, &page); err != nil {
+			return err
+		}
+		for _, summary := range page {
+			ids = append(ids, summary.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := make([]Secret, len(ids))
+	for i, id := range ids {
+		// the listed secrets are not fully populated
+		secret, err := s.Secret(id)
+		if err != nil {
+			return nil, err
+		}
+		secrets[i] = *secret
+	}
+	return secrets, nil
+}
+
 func (s Server) SecretByPath(secretPath string) (*Secret, error) {
+	return s.SecretByPathWithOptions(secretPath, LookupOptions{})
+}
+
+// SecretByPathWithOptions gets the secret with the given folder path and
+// name, as restricted by the given options
+func (s Server) SecretByPathWithOptions(secretPath string, options LookupOptions) (*Secret, error) {
 	secret := new(Secret)
 	// Encode the secret path to be safe for URLs
 	encodedPath := url.QueryEscape(secretPath)
 	queryPath := fmt.Sprintf("0?secretPath=%s", encodedPath)
+	if options.IncludeInactive {
+		queryPath = queryPath + "&includeInactive=true"
+	}
 
 	// Perform the GET request to the 'secrets' resource with the specified path
 	if data, err := s.accessResource("GET", resource, queryPath, nil); err == nil {
@@ -119,8 +186,15 @@ func (s Server) SecretByPath(secretPath string) (*Secret, error) {
 		return nil, err
 	}
 
-	// automatically download file attachments and substitute them for the
-	// (dummy) ItemValue, to make the process transparent to the caller
+	if err := s.downloadFiles(secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// downloadFiles automatically downloads file attachments and substitutes them
+// for the (dummy) ItemValue, so as to make the process transparent to the caller
+func (s Server) downloadFiles(secret *Secret) error {
 	for index, element := range secret.Fields {
 		if element.IsFile && element.FileAttachmentID != 0 && element.Filename != "" {
 			path := fmt.Sprintf("%d/fields/%s", secret.ID, element.Slug)
@@ -128,32 +202,37 @@ func (s Server) SecretByPath(secretPath string) (*Secret, error) {
 			if data, err := s.accessResource("GET", resource, path, nil); err == nil {
 				secret.Fields[index].ItemValue = string(data)
 			} else {
-				return nil, err
+				return err
 			}
 		}
 	}
-
-	return secret, nil
+	return nil
 }
 
 func (s Server) CreateSecret(secret Secret) (*Secret, error) {
-	return s.writeSecret(secret, "POST", "/")
+	return s.writeSecret(secret, "POST", "/", s.SecretTemplate)
 }
 
 func (s Server) UpdateSecret(secret Secret) (*Secret, error) {
+	return s.updateSecret(secret, s.SecretTemplate)
+}
+
+func (s Server) updateSecret(secret Secret, templateFor func(int) (*SecretTemplate, error)) (*Secret, error) {
 	if secret.SshKeyArgs != nil && (secret.SshKeyArgs.GenerateSshKeys || secret.SshKeyArgs.GeneratePassphrase) {
 		err := fmt.Errorf("[ERROR] SSH key and passphrase generation is only supported during secret creation. "+
 			"Could not update the secret named '%s'", secret.Name)
 		return nil, err
 	}
 	secret.SshKeyArgs = nil
-	return s.writeSecret(secret, "PUT", strconv.Itoa(secret.ID))
+	return s.writeSecret(secret, "PUT", strconv.Itoa(secret.ID), templateFor)
 }
 
-func (s Server) writeSecret(secret Secret, method string, path string) (*Secret, error) {
+// writeSecret writes the secret with the given method and path, using
+// templateFor to look up the secret template that defines its fields
+func (s Server) writeSecret(secret Secret, method string, path string, templateFor func(int) (*SecretTemplate, error)) (*Secret, error) {
 	writtenSecret := new(Secret)
 
-	template, err := s.SecretTemplate(secret.SecretTemplateID)
+	template, err := templateFor(secret.SecretTemplateID)
 	if err != nil {
 		return nil, err
 	}
@@ -217,6 +296,27 @@ func (s Server) DeleteSecret(id int) error {
 	return err
 }
 
+// RestoreSecret reactivates the secret with the given id after it has been
+// deleted with DeleteSecret
+func (s Server) RestoreSecret(id int) error {
+	_, err := s.accessResource("PUT", resource, fmt.Sprintf("%d/undelete", id), nil)
+	return err
+}
+
+// PurgeSecret requests the permanent erasure of the secret with the given id,
+// which must already be deleted. Erasure is carried out by the server once
+// the request is approved, and only on servers where secret erasure is
+// enabled; otherwise the server returns an error.
+func (s Server) PurgeSecret(id int, notes string) error {
+	input := struct {
+		SecretIDs    []int
+		RequestNotes string
+	}{SecretIDs: []int{id}, RequestNotes: notes}
+
+	_, err := s.accessResource("POST", eraseRequestResource, "/", input)
+	return err
+}
+
 // Field returns the value of the field with the name fieldName
 func (s Secret) Field(fieldName string) (string, bool) {
 	for _, field := range s.Fields {
@@ -241,24 +341,35 @@ func (s Secret) FieldById(fieldId int) (string, bool) {
 	return "", false
 }
 
+// fieldMod is a change to a single field in a PATCH of the general section of a secret
+type fieldMod struct {
+	Slug  string
+	Dirty bool
+	Value interface{}
+}
+
+// dirtyValue is a change to a single setting in a PATCH of the general section of a secret
+type dirtyValue struct {
+	Dirty bool
+	Value interface{}
+}
+
+type fieldMods struct {
+	SecretFields             []fieldMod  `json:",omitempty"`
+	Name, Folder             *dirtyValue `json:",omitempty"`
+	EnableInheritPermissions *dirtyValue `json:",omitempty"`
+	SecretPolicy             *dirtyValue `json:",omitempty"`
+}
+
+// secretPatch is the request body for a PATCH of the general section of a secret
+type secretPatch struct {
+	Data fieldMods
+}
+
 // updateFiles iterates the list of file fields and if the field's item value is empty,
 // deletes the file, otherwise, uploads the contents of the item value as the new/updated
 // file attachment.
 func (s Server) updateFiles(secretId int, fileFields []SecretField) error {
-	type fieldMod struct {
-		Slug  string
-		Dirty bool
-		Value interface{}
-	}
-
-	type fieldMods struct {
-		SecretFields []fieldMod
-	}
-
-	type secretPatch struct {
-		Data fieldMods
-	}
-
 	for _, element := range fileFields {
 		var path string
 		var input interface{}
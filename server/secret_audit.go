@@ -0,0 +1,155 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+)
+
+// SecretAudit is an entry in the audit trail of a secret
+type SecretAudit struct {
+	ID, SecretID                   int
+	Action, User, IPAddress, Notes string
+	MachineName                    string
+	Date                           time.Time
+}
+
+// SecretAuditPage is a page of the audit trail of a secret
+type SecretAuditPage struct {
+	Records           []SecretAudit
+	Skip, Take, Total int
+	HasNext           bool
+}
+
+// PasswordHistoryEntry is a previous password of a secret
+type PasswordHistoryEntry struct {
+	Password, User string
+	Date           time.Time
+}
+
+// SecretAudits gets the given page of the audit trail of the secret with the
+// given id, most recent first
+func (s Server) SecretAudits(id int, paging Paging) (*SecretAuditPage, error) {
+	response := struct {
+		Records []struct {
+			SecretAuditID, SecretID                                  int
+			Action, ByUserDisplayName, IPAddress, Notes, MachineName string
+			DateRecorded                                             string
+		}
+		Skip, Take, Total int
+		HasNext           bool
+	}{}
+	path := fmt.Sprintf("%d/audits?%s", id, paging.query())
+
+	if data, err := s.accessResource("GET", resource, path, nil); err == nil {
+		if err = json.Unmarshal(data, &response); err != nil {
+			log.Printf("[ERROR] error parsing response from /%s/%s: %q", resource, path, data)
+			return nil, err
+		}
+	} else {
+		return nil, err
+	}
+
+	page := &SecretAuditPage{
+		Records: make([]SecretAudit, len(response.Records)),
+		Skip:    response.Skip,
+		Take:    response.Take,
+		Total:   response.Total,
+		HasNext: response.HasNext,
+	}
+	for i, record := range response.Records {
+		page.Records[i] = SecretAudit{
+			ID:          record.SecretAuditID,
+			SecretID:    record.SecretID,
+			Action:      record.Action,
+			User:        record.ByUserDisplayName,
+			IPAddress:   record.IPAddress,
+			Notes:       record.Notes,
+			MachineName: record.MachineName,
+			Date:        parseServerTime(record.DateRecorded),
+		}
+	}
+	return page, nil
+}
+
+// SecretAuditIterator walks the audit trail of a secret one record at a time,
+// fetching pages as they are needed
+type SecretAuditIterator struct {
+	server   Server
+	secretID int
+	paging   Paging
+	page     *SecretAuditPage
+	index    int
+	err      error
+}
+
+// SecretAuditIterator returns an iterator over the whole audit trail of the
+// secret with the given id, fetching pageSize records at a time
+func (s Server) SecretAuditIterator(id, pageSize int) *SecretAuditIterator {
+	return &SecretAuditIterator{server: s, secretID: id, paging: Paging{Take: pageSize}}
+}
+
+// Next advances the iterator, returning false when there are no more records
+// or an error occurred
+func (it *SecretAuditIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if it.page != nil && it.index+1 < len(it.page.Records) {
+		it.index++
+		return true
+	}
+	if it.page != nil && !it.page.HasNext {
+		return false
+	}
+	if it.page != nil {
+		it.paging.Skip += len(it.page.Records)
+	}
+	if it.page, it.err = it.server.SecretAudits(it.secretID, it.paging); it.err != nil {
+		return false
+	}
+	it.index = 0
+	return len(it.page.Records) > 0
+}
+
+// Audit returns the record the iterator is positioned at
+func (it *SecretAuditIterator) Audit() SecretAudit {
+	return it.page.Records[it.index]
+}
+
+// Err returns the error, if any, that stopped the iteration
+func (it *SecretAuditIterator) Err() error {
+	return it.err
+}
+
+// SecretPasswordHistory gets the previous passwords of the secret with the
+// given id. The server only permits this for users who are allowed to view
+// the password history of the secret.
+func (s Server) SecretPasswordHistory(id int) ([]PasswordHistoryEntry, error) {
+	response := struct {
+		Records []struct {
+			Password, UserDisplayName, Date string
+		}
+	}{}
+	path := fmt.Sprintf("%d/password-history", id)
+
+	if data, err := s.accessResource("GET", resource, path, nil); err == nil {
+		if err = json.Unmarshal(data, &response); err != nil {
+			log.Printf("[ERROR] error parsing response from /%s/%s: %q", resource, path, data)
+			return nil, err
+		}
+	} else {
+		return nil, err
+	}
+
+	history := make([]PasswordHistoryEntry, len(response.Records))
+	for i, record := range response.Records {
+		history[i] = PasswordHistoryEntry{
+			Password: record.Password,
+			User:     record.UserDisplayName,
+			Date:     parseServerTime(record.Date),
+		}
+	}
+	return history, nil
+}
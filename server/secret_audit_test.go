@@ -0,0 +1,81 @@
+package server_test
+
+import (
+	"testing"
+
+	"github.com/DelineaXPM/tss-sdk-go/v3/server"
+)
+
+// TestSecretAudits tests reading the audit trail, a page at a time and with
+// the iterator, against the fake
+func TestSecretAudits(t *testing.T) {
+	fake, template, tss := newFakeServer(t)
+	defer fake.Close()
+	secret := addPasswordSecret(t, fake, template, "Audited")
+
+	for i := 0; i < 3; i++ {
+		if _, err := tss.Secret(secret.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tss.UpdateField(secret.ID, "notes", "audited"); err != nil {
+		t.Fatal(err)
+	}
+
+	page, err := tss.SecretAudits(secret.ID, server.Paging{Take: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Records) != 2 || !page.HasNext || page.Records[0].Action != "VIEW" || page.Records[0].User == "" {
+		t.Errorf("expected the first page to hold the 2 most recent audits, found %+v", page)
+	}
+
+	// the read, update and second read of the field update, then the 3 views
+	var actions []string
+	it := tss.SecretAuditIterator(secret.ID, 2)
+	for it.Next() {
+		if audit := it.Audit(); audit.SecretID != secret.ID || audit.Date.IsZero() {
+			t.Errorf("expected an audit of the secret with a date, found %+v", audit)
+		}
+		actions = append(actions, it.Audit().Action)
+	}
+	if err = it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(actions) != page.Total || len(actions) != 6 || actions[1] != "EDIT" {
+		t.Errorf("expected the whole audit trail, most recent first, found %v", actions)
+	}
+
+	if _, err = tss.SecretAudits(1000, server.Paging{}); err == nil {
+		t.Error("expected an error reading the audits of a secret that does not exist")
+	}
+	if it = tss.SecretAuditIterator(1000, 2); it.Next() || it.Err() == nil {
+		t.Error("expected the iterator to stop with an error for a secret that does not exist")
+	}
+}
+
+// TestSecretPasswordHistory tests reading the replaced passwords of a secret
+// against the fake
+func TestSecretPasswordHistory(t *testing.T) {
+	fake, template, tss := newFakeServer(t)
+	defer fake.Close()
+	secret := addPasswordSecret(t, fake, template, "Rotated")
+
+	for _, password := range []string{"second", "third"} {
+		if err := tss.UpdateField(secret.ID, "password", password); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	history, err := tss.SecretPasswordHistory(secret.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[0].Password != "second" || history[1].Password != "s3cret" || history[0].Date.IsZero() {
+		t.Errorf("expected the replaced passwords, most recent first, found %+v", history)
+	}
+
+	if _, err = tss.SecretPasswordHistory(1000); err == nil {
+		t.Error("expected an error reading the password history of a secret that does not exist")
+	}
+}
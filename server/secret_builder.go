@@ -0,0 +1,244 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+)
+
+// ValidationError collects every problem found while validating a secret or a
+// password, so that callers can correct them all at once.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("validation failed: %s", strings.Join(e.Problems, "; "))
+}
+
+// SecretBuilder assembles a Secret for the given template, setting fields by
+// their slug rather than by field ID, and validates the result locally before
+// it is sent to the server.
+type SecretBuilder struct {
+	template *SecretTemplate
+	secret   Secret
+	fields   map[string]SecretField
+	generate map[string]bool
+	problems []string
+}
+
+// NewSecretBuilder returns a SecretBuilder for secrets based on the given
+// template
+func NewSecretBuilder(template *SecretTemplate) *SecretBuilder {
+	b := &SecretBuilder{
+		template: template,
+		fields:   make(map[string]SecretField),
+		generate: make(map[string]bool),
+	}
+	if template != nil {
+		b.secret.SecretTemplateID = template.ID
+	}
+	return b
+}
+
+// Name sets the name of the secret
+func (b *SecretBuilder) Name(name string) *SecretBuilder {
+	b.secret.Name = name
+	return b
+}
+
+// Folder sets the ID of the folder the secret is created in
+func (b *SecretBuilder) Folder(folderID int) *SecretBuilder {
+	b.secret.FolderID = folderID
+	return b
+}
+
+// Site sets the ID of the distributed engine site of the secret
+func (b *SecretBuilder) Site(siteID int) *SecretBuilder {
+	b.secret.SiteID = siteID
+	return b
+}
+
+// Field sets the value of the non-file field identified by the given slug
+func (b *SecretBuilder) Field(slug, value string) *SecretBuilder {
+	templateField, found := b.templateField(slug)
+	if !found {
+		return b
+	}
+	if templateField.IsFile {
+		b.problems = append(b.problems, fmt.Sprintf("field '%s' is a file field and must be set with File", slug))
+		return b
+	}
+	b.fields[slug] = SecretField{FieldID: templateField.SecretTemplateFieldID, Slug: slug, ItemValue: value}
+	return b
+}
+
+// File sets the filename and contents of the file field identified by the
+// given slug
+func (b *SecretBuilder) File(slug, filename, contents string) *SecretBuilder {
+	templateField, found := b.templateField(slug)
+	if !found {
+		return b
+	}
+	if !templateField.IsFile {
+		b.problems = append(b.problems, fmt.Sprintf("field '%s' is not a file field", slug))
+		return b
+	}
+	b.fields[slug] = SecretField{
+		FieldID:   templateField.SecretTemplateFieldID,
+		Slug:      slug,
+		Filename:  filename,
+		ItemValue: contents,
+		IsFile:    true,
+	}
+	return b
+}
+
+// GeneratePassword marks the password field identified by the given slug to
+// have its value generated by the server when the secret is created
+func (b *SecretBuilder) GeneratePassword(slug string) *SecretBuilder {
+	templateField, found := b.templateField(slug)
+	if !found {
+		return b
+	}
+	if !templateField.IsPassword {
+		b.problems = append(b.problems, fmt.Sprintf("field '%s' is not a password field and cannot be generated", slug))
+		return b
+	}
+	b.generate[slug] = true
+	return b
+}
+
+// SshKeys requests that the server generate an SSH key pair, and optionally a
+// private key passphrase, when the secret is created
+func (b *SecretBuilder) SshKeys(generatePassphrase bool) *SecretBuilder {
+	b.secret.SshKeyArgs = &SshKeyArgs{GenerateSshKeys: true, GeneratePassphrase: generatePassphrase}
+	return b
+}
+
+// Generate asks the server to generate a value for every field marked with
+// GeneratePassword
+func (b *SecretBuilder) Generate(s Server) error {
+	for slug := range b.generate {
+		password, err := s.GeneratePassword(slug, b.template)
+		if err != nil {
+			return err
+		}
+		templateField, _ := b.template.GetField(slug)
+		b.fields[slug] = SecretField{FieldID: templateField.SecretTemplateFieldID, Slug: slug, ItemValue: password}
+		delete(b.generate, slug)
+	}
+	return nil
+}
+
+// Validate checks the secret against its template and returns a
+// *ValidationError listing every problem found, or nil if there are none
+func (b *SecretBuilder) Validate() error {
+	problems := append([]string{}, b.problems...)
+
+	if b.template == nil {
+		problems = append(problems, "a secret template is required")
+		return &ValidationError{Problems: problems}
+	}
+	if strings.TrimSpace(b.secret.Name) == "" {
+		problems = append(problems, "a secret name is required")
+	}
+
+	for _, templateField := range b.template.Fields {
+		slug := templateField.FieldSlugName
+		field, set := b.fields[slug]
+
+		if b.generate[slug] {
+			if set && field.ItemValue != "" {
+				problems = append(problems, fmt.Sprintf("field '%s' is set and also marked to be generated", slug))
+			} else {
+				problems = append(problems, fmt.Sprintf("the password for field '%s' has not been generated", slug))
+			}
+			continue
+		}
+		generated := generatedWithSshKeys(templateField, b.secret.SshKeyArgs)
+		if !set || field.ItemValue == "" {
+			if templateField.IsRequired && !generated {
+				problems = append(problems, fmt.Sprintf("field '%s' is required", slug))
+			}
+			continue
+		}
+		if templateField.IsList {
+			problems = append(problems, fmt.Sprintf("field '%s' is a list field of type '%s' and cannot be set directly", slug, templateField.ListType))
+		}
+		if templateField.IsUrl {
+			if u, err := url.ParseRequestURI(field.ItemValue); err != nil || u.Scheme == "" || u.Host == "" {
+				problems = append(problems, fmt.Sprintf("field '%s' must be an absolute URL", slug))
+			}
+		}
+		if generated {
+			problems = append(problems, fmt.Sprintf("field '%s' cannot be set when SSH keys are generated", slug))
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// generatedWithSshKeys reports whether the server fills in the template field
+// when it generates SSH keys as the given arguments ask: the private and
+// public key file fields, and the passphrase field if a passphrase is
+// generated. The fields are recognized as RotateSshKeys recognizes them.
+func generatedWithSshKeys(field SecretTemplateField, args *SshKeyArgs) bool {
+	switch {
+	case args == nil || !args.GenerateSshKeys:
+		return false
+	case field.IsFile:
+		return privateKeyRegex.MatchString(field.FieldSlugName) || publicKeyRegex.MatchString(field.FieldSlugName)
+	case field.IsPassword:
+		return args.GeneratePassphrase && passphraseRegex.MatchString(field.FieldSlugName)
+	}
+	return false
+}
+
+// Build validates the secret and returns it, ready to be passed to
+// CreateSecret
+func (b *SecretBuilder) Build() (*Secret, error) {
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+
+	secret := b.secret
+	secret.Fields = make([]SecretField, 0, len(b.fields))
+	for _, templateField := range b.template.Fields {
+		if field, set := b.fields[templateField.FieldSlugName]; set {
+			secret.Fields = append(secret.Fields, field)
+		}
+	}
+	return &secret, nil
+}
+
+// Create generates any requested passwords, validates the secret and, if it
+// is valid, creates it on the server
+func (b *SecretBuilder) Create(s Server) (*Secret, error) {
+	if err := b.Generate(s); err != nil {
+		return nil, err
+	}
+	secret, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	return s.CreateSecret(*secret)
+}
+
+// templateField looks up the template field with the given slug and records
+// a problem if there is none
+func (b *SecretBuilder) templateField(slug string) (*SecretTemplateField, bool) {
+	if b.template == nil {
+		return nil, false
+	}
+	templateField, found := b.template.GetField(slug)
+	if !found {
+		log.Printf("[DEBUG] the slug '%s' is not defined on the template named '%s'", slug, b.template.Name)
+		b.problems = append(b.problems, fmt.Sprintf("field '%s' is not defined on the secret template with id '%d'", slug, b.template.ID))
+	}
+	return templateField, found
+}
@@ -0,0 +1,82 @@
+package server_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DelineaXPM/tss-sdk-go/v3/server"
+	"github.com/DelineaXPM/tss-sdk-go/v3/tsstest"
+)
+
+// TestSecretBuilderCreate tests generating passwords and creating secrets
+// with a SecretBuilder against the fake
+func TestSecretBuilderCreate(t *testing.T) {
+	fake, template, tss := newFakeServer(t)
+	defer fake.Close()
+
+	builder := server.NewSecretBuilder(&template).Name("DB").Folder(-1).Field("username", "app").GeneratePassword("password")
+	if err := builder.Validate(); err == nil {
+		t.Error("expected a validation error before the password is generated")
+	}
+	if err := builder.Generate(*tss); err != nil {
+		t.Fatal(err)
+	}
+	built, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	generated, _ := built.Field("password")
+	if generated == "" {
+		t.Fatal("expected a generated password")
+	}
+
+	created, err := server.NewSecretBuilder(&template).Name("API").Folder(-1).
+		Field("username", "api").GeneratePassword("password").Create(*tss)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, found := fake.Secret(created.ID)
+	if !found || stored.Name != "API" {
+		t.Fatalf("expected the secret to be created, found %+v", stored)
+	}
+	if password, _ := stored.Field("password"); password == "" || password == generated {
+		t.Errorf("expected a newly generated password, found %q", password)
+	}
+
+	before := len(fake.Requests())
+	_, err = server.NewSecretBuilder(&template).Name("Invalid").Field("username", "app").Create(*tss)
+	if _, ok := err.(*server.ValidationError); !ok || !strings.Contains(err.Error(), "field 'password' is required") {
+		t.Errorf("expected a validation error, found %v", err)
+	}
+	if requests := fake.Requests()[before:]; len(requests) != 0 {
+		t.Errorf("expected an invalid secret not to be sent, found %v", requests)
+	}
+}
+
+// TestSecretBuilderCreateSshKeys tests creating a secret whose SSH keys the
+// server generates against the fake
+func TestSecretBuilderCreateSshKeys(t *testing.T) {
+	fake, _, tss := newFakeServer(t)
+	defer fake.Close()
+	sshTemplate := tsstest.SshKeyTemplate()
+	for i := range sshTemplate.Fields {
+		sshTemplate.Fields[i].IsRequired = true
+	}
+	template := fake.AddTemplate(sshTemplate)
+
+	created, err := server.NewSecretBuilder(&template).Name("Host").Folder(-1).
+		Field("machine", "db.example.com").Field("username", "root").Field("password", "Passw0rd.").
+		SshKeys(true).Create(*tss)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, _ := fake.Secret(created.ID)
+	for _, slug := range []string{"private-key", "public-key", "private-key-passphrase"} {
+		if value, _ := stored.Field(slug); value == "" {
+			t.Errorf("expected the server to generate '%s'", slug)
+		}
+	}
+	if password, _ := stored.Field("password"); password != "Passw0rd." {
+		t.Errorf("expected the password that was set, found %q", password)
+	}
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"strings"
+	"testing"
+)
+
+func builderTestTemplate() *SecretTemplate {
+	return &SecretTemplate{
+		Name: "Builder Test Template",
+		ID:   6001,
+		Fields: []SecretTemplateField{
+			{SecretTemplateFieldID: 1, FieldSlugName: "username", IsRequired: true},
+			{SecretTemplateFieldID: 2, FieldSlugName: "password", IsPassword: true, IsRequired: true},
+			{SecretTemplateFieldID: 3, FieldSlugName: "url", IsUrl: true},
+			{SecretTemplateFieldID: 4, FieldSlugName: "private-key", IsFile: true},
+			{SecretTemplateFieldID: 5, FieldSlugName: "hosts", IsList: true, ListType: "Generic"},
+		},
+	}
+}
+
+// TestSecretBuilder tests that SecretBuilder assembles fields by slug
+func TestSecretBuilder(t *testing.T) {
+	secret, err := NewSecretBuilder(builderTestTemplate()).
+		Name("Builder Secret").
+		Folder(7).
+		Site(1).
+		Field("username", "admin").
+		Field("password", "Passw0rd.").
+		Field("url", "https://example.com/login").
+		File("private-key", "id_rsa", "-----BEGIN KEY-----").
+		Build()
+	if err != nil {
+		t.Fatal("calling SecretBuilder.Build:", err)
+	}
+	if !validate("built secret template id", 6001, secret.SecretTemplateID, t) {
+		return
+	}
+	if !validate("built secret folder id", 7, secret.FolderID, t) {
+		return
+	}
+	if !validate("built secret field count", 4, len(secret.Fields), t) {
+		return
+	}
+	if value, found := secret.FieldById(2); !found || value != "Passw0rd." {
+		t.Errorf("expected the password field to be set, found '%s'", value)
+	}
+	if !validate("built secret file name", "id_rsa", secret.Fields[3].Filename, t) {
+		return
+	}
+}
+
+// TestSecretBuilderValidation tests that SecretBuilder reports every problem
+// at once
+func TestSecretBuilderValidation(t *testing.T) {
+	_, err := NewSecretBuilder(builderTestTemplate()).
+		Field("nonexistent", "value").
+		Field("private-key", "not a file").
+		Field("url", "example.com").
+		Field("hosts", "a,b").
+		GeneratePassword("username").
+		Build()
+	validationError, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatalf("expected a *ValidationError, found '%v'", err)
+	}
+	// nonexistent, private-key, username generation, name, username, password, url, hosts
+	if !validate("validation problem count", 8, len(validationError.Problems), t) {
+		t.Log(validationError.Problems)
+	}
+}
+
+// TestSecretBuilderSshKeys tests that generated SSH keys and passphrases
+// satisfy only the required fields the server fills in
+func TestSecretBuilderSshKeys(t *testing.T) {
+	template := &SecretTemplate{
+		Name: "SSH Builder Test Template",
+		ID:   6036,
+		Fields: []SecretTemplateField{
+			{SecretTemplateFieldID: 1, FieldSlugName: "username", IsRequired: true},
+			{SecretTemplateFieldID: 2, FieldSlugName: "password", IsPassword: true, IsRequired: true},
+			{SecretTemplateFieldID: 3, FieldSlugName: "private-key", IsFile: true, IsRequired: true},
+			{SecretTemplateFieldID: 4, FieldSlugName: "public-key", IsFile: true, IsRequired: true},
+			{SecretTemplateFieldID: 5, FieldSlugName: "private-key-passphrase", IsPassword: true, IsRequired: true},
+			{SecretTemplateFieldID: 6, FieldSlugName: "certificate", IsFile: true, IsRequired: true},
+		},
+	}
+
+	secret, err := NewSecretBuilder(template).
+		Name("SSH Builder Secret").
+		Field("username", "admin").
+		Field("password", "Passw0rd.").
+		File("certificate", "id_rsa-cert.pub", "ssh-rsa-cert-v01@openssh.com").
+		SshKeys(true).
+		Build()
+	if err != nil {
+		t.Fatal("calling SecretBuilder.Build:", err)
+	}
+	if secret.SshKeyArgs == nil || !secret.SshKeyArgs.GeneratePassphrase {
+		t.Error("expected SSH key and passphrase generation to be requested")
+	}
+
+	for _, test := range []struct {
+		name     string
+		builder  *SecretBuilder
+		problems []string
+	}{
+		{
+			"the login password and other file fields are still required",
+			NewSecretBuilder(template).Name("SSH").Field("username", "admin").SshKeys(true),
+			[]string{"field 'password' is required", "field 'certificate' is required"},
+		},
+		{
+			"the passphrase is required unless it is generated",
+			NewSecretBuilder(template).Name("SSH").Field("username", "admin").Field("password", "Passw0rd.").
+				File("certificate", "cert", "cert").SshKeys(false),
+			[]string{"field 'private-key-passphrase' is required"},
+		},
+		{
+			"generated fields cannot be set",
+			NewSecretBuilder(template).Name("SSH").Field("username", "admin").Field("password", "Passw0rd.").
+				File("certificate", "cert", "cert").File("public-key", "id_rsa.pub", "ssh-rsa").SshKeys(true),
+			[]string{"field 'public-key' cannot be set when SSH keys are generated"},
+		},
+	} {
+		err := test.builder.Validate()
+		validationError, ok := err.(*ValidationError)
+		if !ok {
+			t.Errorf("%s: expected a *ValidationError, found '%v'", test.name, err)
+			continue
+		}
+		if strings.Join(validationError.Problems, "; ") != strings.Join(test.problems, "; ") {
+			t.Errorf("%s: expected %q, found %q", test.name, test.problems, validationError.Problems)
+		}
+	}
+}
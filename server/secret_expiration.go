@@ -0,0 +1,200 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// secretPolicyResource is the HTTP URL path component for the secret policies
+// resource
+const secretPolicyResource = "secret-policy"
+
+// expiringPageSize is the number of secrets requested per page by
+// ExpiringSecrets
+const expiringPageSize = 500
+
+// SecretPolicy is a named set of settings, such as the password change
+// schedule, that can be applied to secrets
+type SecretPolicy struct {
+	ID                int
+	Name, Description string
+	Active            bool
+}
+
+// secretPolicyRecord is a secret policy as the server represents it
+type secretPolicyRecord struct {
+	SecretPolicyID                            int
+	SecretPolicyName, SecretPolicyDescription string
+	Active                                    bool
+}
+
+func (r secretPolicyRecord) policy() *SecretPolicy {
+	return &SecretPolicy{
+		ID:          r.SecretPolicyID,
+		Name:        r.SecretPolicyName,
+		Description: r.SecretPolicyDescription,
+		Active:      r.Active,
+	}
+}
+
+// ExpiringSecret summarizes a secret that expires soon
+type ExpiringSecret struct {
+	ID, FolderID, SecretTemplateID int
+	Name                           string
+	DaysUntilExpiration            int
+}
+
+// Expiration returns the time the secret expires, and a boolean indicating
+// whether the secret expires at all
+func (s Secret) Expiration() (time.Time, bool) {
+	if s.ExpirationDate == "" {
+		return time.Time{}, false
+	}
+	expiration := parseServerTime(s.ExpirationDate)
+	return expiration, !expiration.IsZero()
+}
+
+// DaysUntilExpiration returns the number of whole days until the secret
+// expires, which is negative if it has already expired, and a boolean
+// indicating whether the secret expires at all
+func (s Secret) DaysUntilExpiration() (int, bool) {
+	expiration, expires := s.Expiration()
+	if !expires {
+		return 0, false
+	}
+	return int(time.Until(expiration).Hours() / 24), true
+}
+
+// Expired reports whether the secret has an expiration date in the past
+func (s Secret) Expired() bool {
+	expiration, expires := s.Expiration()
+	return expires && !time.Now().Before(expiration)
+}
+
+// SetSecretExpiration sets the secret with the given id to expire at the
+// given time
+func (s Server) SetSecretExpiration(id int, expiration time.Time) error {
+	return s.patchExpiration(id, "Date", expiration.UTC().Format(time.RFC3339))
+}
+
+// ClearSecretExpiration removes the expiration of the secret with the given id
+func (s Server) ClearSecretExpiration(id int) error {
+	return s.patchExpiration(id, "None", nil)
+}
+
+func (s Server) patchExpiration(id int, expirationType string, expirationDate interface{}) error {
+	input := struct {
+		Data struct{ ExpirationType, ExpirationDate dirtyValue }
+	}{}
+	input.Data.ExpirationType = dirtyValue{Dirty: true, Value: expirationType}
+	input.Data.ExpirationDate = dirtyValue{Dirty: true, Value: expirationDate}
+
+	_, err := s.accessResource("PATCH", resource, fmt.Sprintf("%d/expiration", id), input)
+	return err
+}
+
+// ExpiringSecrets gets the active secrets that expire within the given number
+// of days, including those that have already expired, soonest first. Every
+// page of secrets is read, because the order in which the server returns them
+// cannot be relied on; they are sorted once they have all been read.
+func (s Server) ExpiringSecrets(days int) ([]ExpiringSecret, error) {
+	expiring := make([]ExpiringSecret, 0)
+	query := url.Values{
+		"filter.includeInactive": {"false"},
+		"sortBy[0].name":         {"daysUntilExpiration"},
+		"sortBy[0].direction":    {"asc"},
+	}.Encode()
+
+	err := s.listPages(resource, "", query, expiringPageSize, func(data json.RawMessage) error {
+		var page []struct {
+			ID, FolderID, SecretTemplateID int
+			Name                           string
+			DaysUntilExpiration            *int
+		}
+		if err := json.Unmarshal(data, &page); err != nil {
+			return err
+		}
+		for _, summary := range page {
+			if summary.DaysUntilExpiration == nil || *summary.DaysUntilExpiration > days {
+				continue
+			}
+			expiring = append(expiring, ExpiringSecret{
+				ID:                  summary.ID,
+				FolderID:            summary.FolderID,
+				SecretTemplateID:    summary.SecretTemplateID,
+				Name:                summary.Name,
+				DaysUntilExpiration: *summary.DaysUntilExpiration,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(expiring, func(i, j int) bool {
+		return expiring[i].DaysUntilExpiration < expiring[j].DaysUntilExpiration
+	})
+	return expiring, nil
+}
+
+// SecretPolicy gets the secret policy with the given id, such as the
+// SecretPolicyID of a Secret
+func (s Server) SecretPolicy(id int) (*SecretPolicy, error) {
+	record := new(secretPolicyRecord)
+
+	if data, err := s.accessResource("GET", secretPolicyResource, strconv.Itoa(id), nil); err == nil {
+		if err = json.Unmarshal(data, record); err != nil {
+			log.Printf("[ERROR] error parsing response from /%s/%d: %q", secretPolicyResource, id, data)
+			return nil, err
+		}
+	} else {
+		return nil, err
+	}
+	return record.policy(), nil
+}
+
+// SecretPolicyByName gets the secret policy with the given name, and a boolean
+// indicating whether there is such a policy
+func (s Server) SecretPolicyByName(name string) (*SecretPolicy, bool, error) {
+	var found *SecretPolicy
+	query := fmt.Sprintf("filter.secretPolicyName=%s&filter.includeInactive=true", url.QueryEscape(name))
+
+	err := s.listAll(secretPolicyResource, "search", query, func(data json.RawMessage) error {
+		var page []secretPolicyRecord
+		if err := json.Unmarshal(data, &page); err != nil {
+			return err
+		}
+		for _, record := range page {
+			if found == nil && strings.EqualFold(record.SecretPolicyName, name) {
+				found = record.policy()
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	if found == nil {
+		log.Printf("[DEBUG] no secret policy named '%s'", name)
+	}
+	return found, found != nil, nil
+}
+
+// ApplySecretPolicy applies the secret policy with the given name to the
+// secret with the given id
+func (s Server) ApplySecretPolicy(secretID int, policyName string) error {
+	policy, found, err := s.SecretPolicyByName(policyName)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("there is no secret policy named '%s'", policyName)
+	}
+	return s.patchGeneral(secretID, fieldMods{SecretPolicy: &dirtyValue{Dirty: true, Value: policy.ID}})
+}
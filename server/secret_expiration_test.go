@@ -0,0 +1,29 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+// TestSecretExpiration tests the expiration accessors of Secret
+func TestSecretExpiration(t *testing.T) {
+	if _, expires := (Secret{}).Expiration(); expires {
+		t.Error("expected a secret without an expiration date not to expire")
+	}
+
+	expired := Secret{ExpirationDate: time.Now().Add(-49 * time.Hour).UTC().Format("2006-01-02T15:04:05")}
+	if !expired.Expired() {
+		t.Error("expected a secret with an expiration date in the past to be expired")
+	}
+	if days, _ := expired.DaysUntilExpiration(); days != -2 {
+		t.Errorf("expected the expired secret to have expired 2 whole days ago, found %d", days)
+	}
+
+	expiring := Secret{ExpirationDate: time.Now().Add(10*24*time.Hour + time.Hour).Format(time.RFC3339)}
+	if expiring.Expired() {
+		t.Error("expected a secret with an expiration date in the future not to be expired")
+	}
+	if days, expires := expiring.DaysUntilExpiration(); !expires || days != 10 {
+		t.Errorf("expected the expiring secret to expire in 10 days, found %d", days)
+	}
+}
@@ -0,0 +1,158 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ConcurrentModificationError is returned when a secret was changed on the
+// server by someone else while it was being updated, or after the caller read
+// it and before the caller's update was applied
+type ConcurrentModificationError struct {
+	SecretID int
+	Slugs    []string
+	// Applied is set if the update was made regardless, which happens when
+	// the change is only noticed once the update has been sent
+	Applied bool
+}
+
+func (e *ConcurrentModificationError) Error() string {
+	outcome := "the update was not applied"
+	if e.Applied {
+		outcome = "the update was applied"
+	}
+	return fmt.Sprintf("the secret with id '%d' was modified concurrently; changed fields: %s; %s",
+		e.SecretID, strings.Join(e.Slugs, ", "), outcome)
+}
+
+// UpdateField sets the value of the single non-file field identified by the
+// given slug on the secret with the given id, without rewriting the rest of
+// the secret. It detects concurrent modification as UpdateFields does.
+func (s Server) UpdateField(secretID int, slug, value string) error {
+	return s.UpdateFields(secretID, map[string]string{slug: value})
+}
+
+// UpdateFields sets the values of the non-file fields identified by the keys
+// of the given map on the secret with the given id, in a single request. A
+// *ValidationError is returned, and nothing is updated, if the secret has no
+// field with one of the slugs or it is a file field.
+//
+// The fields of the secret are read before and after the update. If any field
+// that was not updated changed in between, or an updated field does not hold
+// the new value afterwards, the secret was modified concurrently, and a
+// *ConcurrentModificationError naming the fields is returned. The update has
+// been applied by then: Secret Server does not enforce the check, so it is
+// advisory, and a change made and overwritten between the two reads goes
+// unnoticed.
+func (s Server) UpdateFields(secretID int, values map[string]string) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	before, err := s.secretWithoutFiles(secretID, LookupOptions{})
+	if err != nil {
+		return err
+	}
+	return s.updateFields(before, values)
+}
+
+// UpdateFieldsIfUnmodified behaves like UpdateFields, but first checks that
+// none of the fields of the secret have changed on the server since original
+// was read. If any have, no update is made and a *ConcurrentModificationError
+// naming them is returned.
+func (s Server) UpdateFieldsIfUnmodified(original *Secret, values map[string]string) error {
+	current, err := s.secretWithoutFiles(original.ID, LookupOptions{})
+	if err != nil {
+		return err
+	}
+
+	if changed := original.changedFields(current); len(changed) > 0 {
+		return &ConcurrentModificationError{SecretID: original.ID, Slugs: changed}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	return s.updateFields(current, values)
+}
+
+// updateFields patches the fields of the secret, which was read just before,
+// then reads it again to detect concurrent modification. Slugs that the
+// secret does not have, or that are file fields, are rejected with a
+// *ValidationError before anything is sent.
+func (s Server) updateFields(before *Secret, values map[string]string) error {
+	slugs := make([]string, 0, len(values))
+	for slug := range values {
+		slugs = append(slugs, slug)
+	}
+	sort.Strings(slugs)
+
+	fields := make(map[string]SecretField, len(before.Fields))
+	for _, field := range before.Fields {
+		fields[field.Slug] = field
+	}
+	var problems []string
+	for _, slug := range slugs {
+		if field, found := fields[slug]; !found {
+			problems = append(problems, fmt.Sprintf("the secret with id '%d' has no field '%s'", before.ID, slug))
+		} else if field.IsFile {
+			problems = append(problems, fmt.Sprintf("field '%s' is a file field and cannot be updated with its value", slug))
+		}
+	}
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+
+	mods := make([]fieldMod, len(slugs))
+	for i, slug := range slugs {
+		mods[i] = fieldMod{Slug: slug, Dirty: true, Value: values[slug]}
+	}
+
+	if err := s.patchGeneral(before.ID, fieldMods{SecretFields: mods}); err != nil {
+		return err
+	}
+
+	after, err := s.secretWithoutFiles(before.ID, LookupOptions{})
+	if err != nil {
+		return err
+	}
+
+	expected := *before
+	expected.Fields = make([]SecretField, len(before.Fields))
+	for i, field := range before.Fields {
+		if value, found := values[field.Slug]; found {
+			field.ItemValue = value
+		}
+		expected.Fields[i] = field
+	}
+	if changed := expected.changedFields(after); len(changed) > 0 {
+		return &ConcurrentModificationError{SecretID: before.ID, Slugs: changed, Applied: true}
+	}
+	return nil
+}
+
+// changedFields returns the slugs of the fields whose values differ between
+// this secret and the given one. File fields are compared by their attachment
+// ID, since their contents are not part of the secret itself.
+func (s Secret) changedFields(other *Secret) []string {
+	otherFields := make(map[string]SecretField, len(other.Fields))
+	for _, field := range other.Fields {
+		otherFields[field.Slug] = field
+	}
+
+	var changed []string
+	for _, field := range s.Fields {
+		otherField, found := otherFields[field.Slug]
+		switch {
+		case !found:
+			changed = append(changed, field.Slug)
+		case field.IsFile:
+			if field.FileAttachmentID != otherField.FileAttachmentID {
+				changed = append(changed, field.Slug)
+			}
+		case field.ItemValue != otherField.ItemValue:
+			changed = append(changed, field.Slug)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,140 @@
+package server_test
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/DelineaXPM/tss-sdk-go/v3/server"
+	"github.com/DelineaXPM/tss-sdk-go/v3/tsstest"
+)
+
+// interfere returns a transport that calls change, which may modify the
+// secret through another client, before or after sending the first PATCH
+func interfere(t *testing.T, fake *tsstest.Server, after bool, change func(other *server.Server) error) http.RoundTripper {
+	other, err := server.New(fake.Configuration())
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := false
+	return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Method != "PATCH" || done {
+			return http.DefaultTransport.RoundTrip(req)
+		}
+		done = true
+		if !after {
+			if err := change(other); err != nil {
+				t.Fatal(err)
+			}
+			return http.DefaultTransport.RoundTrip(req)
+		}
+		response, err := http.DefaultTransport.RoundTrip(req)
+		if err == nil {
+			err = change(other)
+		}
+		return response, err
+	})
+}
+
+// TestUpdateFields tests field updates and the detection of concurrent
+// modification against the fake
+func TestUpdateFields(t *testing.T) {
+	fake, template, tss := newFakeServer(t)
+	defer fake.Close()
+	secret := addPasswordSecret(t, fake, template, "DB")
+
+	if err := tss.UpdateFields(secret.ID, map[string]string{"password": "n3w", "notes": "rotated"}); err != nil {
+		t.Fatal(err)
+	}
+	stored, _ := fake.Secret(secret.ID)
+	if password, _ := stored.Field("password"); password != "n3w" {
+		t.Errorf("expected the updated password, found %q", password)
+	}
+
+	if err := tss.UpdateField(secret.ID+100, "password", "n3w"); err == nil {
+		t.Error("expected an error updating a secret that does not exist")
+	}
+
+	// unknown slugs and file fields are rejected before the PATCH is sent
+	sshTemplate := fake.AddTemplate(tsstest.SshKeyTemplate())
+	host, err := fake.AddSecret(server.Secret{Name: "Host", SecretTemplateID: sshTemplate.ID, FolderID: -1,
+		Fields: []server.SecretField{{Slug: "private-key", ItemValue: "KEY", Filename: "id_rsa"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		id     int
+		values map[string]string
+	}{
+		{secret.ID, map[string]string{"no-such-field": "value", "password": "n3w"}},
+		{host.ID, map[string]string{"private-key": "not a file"}},
+	} {
+		before := len(fake.Requests())
+		var validationErr *server.ValidationError
+		if err := tss.UpdateFields(test.id, test.values); !errors.As(err, &validationErr) || len(validationErr.Problems) != 1 {
+			t.Errorf("expected a validation error updating %v, found %v", test.values, err)
+		}
+		for _, request := range fake.Requests()[before:] {
+			if strings.HasPrefix(request, "PATCH ") {
+				t.Errorf("expected nothing to be sent updating %v, found %s", test.values, request)
+			}
+		}
+	}
+	stored, _ = fake.Secret(secret.ID)
+	if password, _ := stored.Field("password"); password != "n3w" {
+		t.Errorf("expected the password not to be updated with the invalid field, found %q", password)
+	}
+
+	tests := []struct {
+		name    string
+		after   bool
+		slug    string
+		changed string
+	}{
+		{"another field changed", false, "username", "username"},
+		{"updated field overwritten", true, "password", "password"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tss.Transport = interfere(t, fake, test.after, func(other *server.Server) error {
+				return other.UpdateField(secret.ID, test.slug, "someone-else")
+			})
+
+			err := tss.UpdateField(secret.ID, "password", "m1ne")
+			var conflict *server.ConcurrentModificationError
+			if !errors.As(err, &conflict) {
+				t.Fatalf("expected a ConcurrentModificationError, found %v", err)
+			}
+			if strings.Join(conflict.Slugs, ",") != test.changed || !conflict.Applied {
+				t.Errorf("expected the applied update to report %q as changed, found %+v", test.changed, conflict)
+			}
+		})
+	}
+}
+
+// TestUpdateFieldsIfUnmodified tests that a secret changed since it was read
+// is not updated
+func TestUpdateFieldsIfUnmodified(t *testing.T) {
+	fake, template, tss := newFakeServer(t)
+	defer fake.Close()
+	secret := addPasswordSecret(t, fake, template, "DB")
+
+	original, err := tss.Secret(secret.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = tss.UpdateFieldsIfUnmodified(original, map[string]string{"password": "n3w"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var conflict *server.ConcurrentModificationError
+	err = tss.UpdateFieldsIfUnmodified(original, map[string]string{"username": "other"})
+	if !errors.As(err, &conflict) || conflict.Applied || strings.Join(conflict.Slugs, ",") != "password" {
+		t.Fatalf("expected an unapplied update with a changed password, found %v", err)
+	}
+	stored, _ := fake.Secret(secret.ID)
+	if username, _ := stored.Field("username"); username != "app" {
+		t.Errorf("expected the username not to be updated, found %q", username)
+	}
+}
@@ -0,0 +1,31 @@
+package server
+
+import (
+	"testing"
+)
+
+// TestChangedFields tests the detection of fields that were modified
+// concurrently
+func TestChangedFields(t *testing.T) {
+	original := Secret{ID: 1, Fields: []SecretField{
+		{Slug: "username", ItemValue: "admin"},
+		{Slug: "password", ItemValue: "Passw0rd."},
+		{Slug: "private-key", IsFile: true, FileAttachmentID: 10, ItemValue: "key contents"},
+	}}
+	current := Secret{ID: 1, Fields: []SecretField{
+		{Slug: "username", ItemValue: "admin"},
+		{Slug: "password", ItemValue: "Changed!"},
+		{Slug: "private-key", IsFile: true, FileAttachmentID: 10, ItemValue: "*** Not Valid For Display ***"},
+	}}
+
+	changed := original.changedFields(&current)
+	if !validate("changed field count", 1, len(changed), t) {
+		return
+	}
+	validate("changed field slug", "password", changed[0], t)
+
+	current.Fields[2].FileAttachmentID = 11
+	if changed = original.changedFields(&current); !validate("changed field count", 2, len(changed), t) {
+		return
+	}
+}
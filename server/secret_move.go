@@ -0,0 +1,69 @@
+package server
+
+import (
+	"fmt"
+	"log"
+)
+
+// MoveSecret moves the secret with the given id to the folder with the given id
+func (s Server) MoveSecret(id, folderID int) error {
+	return s.patchGeneral(id, fieldMods{Folder: &dirtyValue{Dirty: true, Value: folderID}})
+}
+
+// RenameSecret changes the name of the secret with the given id
+func (s Server) RenameSecret(id int, name string) error {
+	return s.patchGeneral(id, fieldMods{Name: &dirtyValue{Dirty: true, Value: name}})
+}
+
+// CopySecret creates a copy of the secret with the given id, including its
+// file attachments, named newName in the folder with the given id. If
+// copyPermissions is true, the permissions granted on the original are also
+// granted on the copy; if copying them fails, the copy is returned along with
+// the error.
+func (s Server) CopySecret(id, targetFolderID int, newName string, copyPermissions bool) (*Secret, error) {
+	original, err := s.Secret(id)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := *original
+	secret.ID = 0
+	secret.Name = newName
+	secret.FolderID = targetFolderID
+	secret.SshKeyArgs = nil
+	secret.Fields = make([]SecretField, len(original.Fields))
+	for i, field := range original.Fields {
+		field.ItemID = 0
+		field.FileAttachmentID = 0
+		secret.Fields[i] = field
+	}
+
+	copied, err := s.CreateSecret(secret)
+	if err != nil {
+		return nil, err
+	}
+	if !copyPermissions {
+		return copied, nil
+	}
+
+	permissions, err := s.SecretPermissions(id)
+	if err != nil {
+		return copied, err
+	}
+	for _, permission := range permissions {
+		permission.SecretID = copied.ID
+		if _, err := s.AddSecretPermission(permission); err != nil {
+			log.Printf("[ERROR] copying permission '%d' from secret '%d' to secret '%d': %s", permission.ID, id, copied.ID, err)
+			return copied, fmt.Errorf("the secret was copied to id '%d', but its permissions were not: %w", copied.ID, err)
+		}
+	}
+	return copied, nil
+}
+
+// patchGeneral applies the given modifications to the general section of the
+// secret with the given id
+func (s Server) patchGeneral(id int, mods fieldMods) error {
+	path := fmt.Sprintf("%d/general", id)
+	_, err := s.accessResource("PATCH", resource, path, secretPatch{Data: mods})
+	return err
+}
@@ -0,0 +1,80 @@
+package server_test
+
+import (
+	"testing"
+
+	"github.com/DelineaXPM/tss-sdk-go/v3/server"
+	"github.com/DelineaXPM/tss-sdk-go/v3/tsstest"
+)
+
+// TestMoveAndRenameSecret tests moving and renaming a secret against the fake
+func TestMoveAndRenameSecret(t *testing.T) {
+	fake, template, tss := newFakeServer(t)
+	defer fake.Close()
+	fake.AddFolder(7, "/Prod")
+	secret := addPasswordSecret(t, fake, template, "DB")
+
+	if err := tss.MoveSecret(secret.ID, 7); err != nil {
+		t.Fatal(err)
+	}
+	if err := tss.RenameSecret(secret.ID, "Database"); err != nil {
+		t.Fatal(err)
+	}
+	if moved, err := tss.SecretByPath("/Prod/Database"); err != nil || moved.ID != secret.ID {
+		t.Errorf("expected to find the secret at its new path, found %+v (%v)", moved, err)
+	}
+
+	if err := tss.MoveSecret(secret.ID, 8); err == nil {
+		t.Error("expected an error moving a secret to a folder that does not exist")
+	}
+	if err := tss.RenameSecret(1000, "Database"); err == nil {
+		t.Error("expected an error renaming a secret that does not exist")
+	}
+}
+
+// TestCopySecret tests copying a secret, with its file attachments and
+// permissions, against the fake
+func TestCopySecret(t *testing.T) {
+	fake, _, tss := newFakeServer(t)
+	defer fake.Close()
+	fake.AddFolder(7, "/Prod")
+	template := fake.AddTemplate(tsstest.SshKeyTemplate())
+	original, err := fake.AddSecret(server.Secret{Name: "Host", SecretTemplateID: template.ID, FolderID: -1,
+		Fields: []server.SecretField{
+			{Slug: "username", ItemValue: "root"},
+			{Slug: "private-key", ItemValue: "private key contents", Filename: "id_rsa"},
+		}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tss.AddSecretPermission(server.SecretPermission{
+		SecretID: original.ID, UserID: 3, SecretAccessRoleName: server.SecretRoleEdit}); err != nil {
+		t.Fatal(err)
+	}
+
+	copied, err := tss.CopySecret(original.ID, 7, "Host copy", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, _ := fake.Secret(copied.ID)
+	key, _ := stored.Field("private-key")
+	if copied.ID == original.ID || stored.FolderID != 7 || stored.Name != "Host copy" || key != "private key contents" {
+		t.Errorf("expected a copy with the file attachment, found %+v", stored)
+	}
+	permissions, err := tss.SecretPermissions(copied.ID)
+	if err != nil || len(permissions) != 1 || permissions[0].UserID != 3 {
+		t.Errorf("expected the permission to be copied, found %+v (%v)", permissions, err)
+	}
+
+	withoutPermissions, err := tss.CopySecret(original.ID, -1, "Host copy 2", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if permissions, _ = tss.SecretPermissions(withoutPermissions.ID); len(permissions) != 0 {
+		t.Errorf("expected no permissions on the copy, found %+v", permissions)
+	}
+
+	if _, err = tss.CopySecret(1000, 7, "Missing copy", false); err == nil {
+		t.Error("expected an error copying a secret that does not exist")
+	}
+}
@@ -0,0 +1,62 @@
+package server_test
+
+import (
+	"testing"
+
+	"github.com/DelineaXPM/tss-sdk-go/v3/server"
+)
+
+// TestInactiveSecrets tests looking up, restoring and purging deleted secrets
+// against the fake
+func TestInactiveSecrets(t *testing.T) {
+	fake, template, tss := newFakeServer(t)
+	defer fake.Close()
+	fake.AddFolder(7, "/Prod")
+	secret := addPasswordSecret(t, fake, template, "Retired")
+	if err := tss.MoveSecret(secret.ID, 7); err != nil {
+		t.Fatal(err)
+	}
+	inactive := server.LookupOptions{IncludeInactive: true}
+
+	if err := tss.DeleteSecret(secret.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tss.Secret(secret.ID); err == nil {
+		t.Error("expected a deleted secret not to be found")
+	}
+	if found, err := tss.SecretWithOptions(secret.ID, inactive); err != nil || found.Active {
+		t.Errorf("expected to find the inactive secret, found %+v (%v)", found, err)
+	}
+	if found, err := tss.SecretsWithOptions("app", "username", inactive); err != nil || len(found) != 1 {
+		t.Errorf("expected a search to find the inactive secret, found %+v (%v)", found, err)
+	}
+	if found, _ := tss.Secrets("app", "username"); len(found) != 0 {
+		t.Errorf("expected a search not to find the inactive secret, found %+v", found)
+	}
+	if found, err := tss.SecretByPathWithOptions("/Prod/Retired", inactive); err != nil || found.ID != secret.ID {
+		t.Errorf("expected to find the inactive secret by path, found %+v (%v)", found, err)
+	}
+
+	if err := tss.RestoreSecret(secret.ID); err != nil {
+		t.Fatal(err)
+	}
+	if found, err := tss.Secret(secret.ID); err != nil || !found.Active {
+		t.Errorf("expected the restored secret to be active, found %+v (%v)", found, err)
+	}
+
+	if err := tss.PurgeSecret(secret.ID, "decommissioned"); err == nil {
+		t.Error("expected an error purging an active secret")
+	}
+	if err := tss.DeleteSecret(secret.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := tss.PurgeSecret(secret.ID, "decommissioned"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tss.SecretWithOptions(secret.ID, inactive); err == nil {
+		t.Error("expected a purged secret not to be found")
+	}
+	if err := tss.RestoreSecret(secret.ID); err == nil {
+		t.Error("expected an error restoring a purged secret")
+	}
+}
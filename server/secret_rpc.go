@@ -0,0 +1,274 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Heartbeat states reported by Secret Server
+const (
+	HeartbeatPending         = "Pending"
+	HeartbeatProcessing      = "Processing"
+	HeartbeatSuccess         = "Success"
+	HeartbeatFailed          = "Failed"
+	HeartbeatUnableToConnect = "UnableToConnect"
+)
+
+// DefaultPollInterval is how long the Wait helpers sleep between status
+// requests unless WaitOptions say otherwise
+const DefaultPollInterval = 5 * time.Second
+
+// WaitOptions control how the Wait helpers poll the server
+type WaitOptions struct {
+	// Timeout is how long to wait for the operation to complete
+	Timeout time.Duration
+	// PollInterval is how long to sleep between status requests;
+	// DefaultPollInterval if zero
+	PollInterval time.Duration
+}
+
+func (o WaitOptions) pollInterval() time.Duration {
+	if o.PollInterval <= 0 {
+		return DefaultPollInterval
+	}
+	return o.PollInterval
+}
+
+// HeartbeatStatus is the result of the most recent heartbeat of a secret
+type HeartbeatStatus struct {
+	SecretID  int
+	Status    string
+	CheckedAt time.Time
+}
+
+// Complete reports whether the heartbeat has finished, successfully or not
+func (h HeartbeatStatus) Complete() bool {
+	return h.Status != "" && h.Status != HeartbeatPending && h.Status != HeartbeatProcessing
+}
+
+// Succeeded reports whether the heartbeat was able to validate the credentials
+func (h HeartbeatStatus) Succeeded() bool {
+	return h.Status == HeartbeatSuccess
+}
+
+// RPCStatus is the state of the most recent remote password change of a secret
+type RPCStatus struct {
+	SecretID        int
+	AttemptedAt     time.Time
+	OutOfSync       bool
+	OutOfSyncReason string
+}
+
+// RPCResult is the outcome of a remote password change
+type RPCResult struct {
+	RPCStatus
+	Succeeded bool
+}
+
+// secretSummary holds the members of the secret summary that describe RPC
+// and heartbeat activity
+type secretSummary struct {
+	ID                        int
+	LastHeartBeatStatus       string
+	LastHeartBeatCheck        string
+	LastPasswordChangeAttempt string
+	IsOutOfSync               bool
+	OutOfSyncReason           string
+}
+
+// ChangePassword asks Secret Server to change the password of the secret with
+// the given id on the target system (a remote password change). If
+// newPassword is empty, a password is generated for the password field of the
+// secret's template. The change happens asynchronously; read the RPCStatus
+// before requesting it, and pass it to WaitForPasswordChange to wait for the
+// change to complete.
+func (s Server) ChangePassword(id int, newPassword string) error {
+	if newPassword == "" {
+		password, err := s.generateSecretPassword(id)
+		if err != nil {
+			return err
+		}
+		newPassword = password
+	}
+
+	path := fmt.Sprintf("%d/change-password", id)
+	input := struct{ NewPassword string }{NewPassword: newPassword}
+
+	_, err := s.accessResource("POST", resource, path, input)
+	return err
+}
+
+// Heartbeat asks Secret Server to validate the credentials of the secret with
+// the given id against the target system. The heartbeat happens
+// asynchronously; read the HeartbeatStatus before requesting it, and pass it
+// to WaitForHeartbeat to wait for the heartbeat to complete.
+func (s Server) Heartbeat(id int) error {
+	_, err := s.accessResource("POST", resource, fmt.Sprintf("%d/heartbeat", id), nil)
+	return err
+}
+
+// HeartbeatStatus returns the result of the most recent heartbeat of the
+// secret with the given id
+func (s Server) HeartbeatStatus(id int) (*HeartbeatStatus, error) {
+	summary, err := s.secretSummary(id)
+	if err != nil {
+		return nil, err
+	}
+	return &HeartbeatStatus{
+		SecretID:  id,
+		Status:    summary.LastHeartBeatStatus,
+		CheckedAt: parseServerTime(summary.LastHeartBeatCheck),
+	}, nil
+}
+
+// RPCStatus returns the state of the most recent remote password change of
+// the secret with the given id
+func (s Server) RPCStatus(id int) (*RPCStatus, error) {
+	summary, err := s.secretSummary(id)
+	if err != nil {
+		return nil, err
+	}
+	return &RPCStatus{
+		SecretID:        id,
+		AttemptedAt:     parseServerTime(summary.LastPasswordChangeAttempt),
+		OutOfSync:       summary.IsOutOfSync,
+		OutOfSyncReason: summary.OutOfSyncReason,
+	}, nil
+}
+
+// WaitForHeartbeat polls the heartbeat status of the secret with the given id
+// until a heartbeat other than the previous one, as returned by
+// HeartbeatStatus before the heartbeat was requested, has completed, or until
+// the timeout elapses. Heartbeats are told apart by the time the server
+// checked them, so the clocks of the client and server need not agree.
+func (s Server) WaitForHeartbeat(id int, previous *HeartbeatStatus, options WaitOptions) (*HeartbeatStatus, error) {
+	if previous == nil {
+		previous = new(HeartbeatStatus)
+	}
+	interval := options.pollInterval()
+	deadline := time.Now().Add(options.Timeout)
+	for {
+		status, err := s.HeartbeatStatus(id)
+		if err != nil {
+			return nil, err
+		}
+		if status.Complete() && !status.CheckedAt.IsZero() && !status.CheckedAt.Equal(previous.CheckedAt) {
+			return status, nil
+		}
+		if time.Now().Add(interval).After(deadline) {
+			return status, fmt.Errorf("timed out waiting for the heartbeat of the secret with id '%d'", id)
+		}
+		log.Printf("[DEBUG] heartbeat of secret '%d' is '%s', waiting %s", id, status.Status, interval)
+		time.Sleep(interval)
+	}
+}
+
+// WaitForPasswordChange polls the RPC status of the secret with the given id
+// until a password change other than the previous one, as returned by
+// RPCStatus before the change was requested, has completed, or until the
+// timeout elapses. Changes are told apart by the time the server recorded the
+// attempt, so the clocks of the client and server need not agree.
+//
+// The attempt time is recorded when a change starts, so it alone does not mean
+// that the change has completed: the change has succeeded once the password
+// history of the secret gains an entry dated at or after the attempt, and has
+// failed once the secret is marked out of sync. A failed change is reported by
+// the Succeeded member of the result rather than as an error.
+//
+// Success is recognized only through the password history, so the user needs
+// permission to view the password history of the secret; without it, the
+// error reading the history is returned.
+//
+// A secret that was already out of sync stays so while the change is
+// retried, so for such a secret only success is recognized before the
+// timeout, after which a change that is still out of sync is reported as
+// failed.
+func (s Server) WaitForPasswordChange(id int, previous *RPCStatus, options WaitOptions) (*RPCResult, error) {
+	if previous == nil {
+		previous = new(RPCStatus)
+	}
+	interval := options.pollInterval()
+	deadline := time.Now().Add(options.Timeout)
+	for {
+		status, err := s.RPCStatus(id)
+		if err != nil {
+			return nil, err
+		}
+
+		attempted := !status.AttemptedAt.IsZero() && !status.AttemptedAt.Equal(previous.AttemptedAt)
+		if attempted {
+			changed, err := s.passwordChangedSince(id, status.AttemptedAt)
+			if err != nil {
+				return nil, err
+			}
+			if changed {
+				return &RPCResult{RPCStatus: *status, Succeeded: true}, nil
+			}
+			if status.OutOfSync && !previous.OutOfSync {
+				return &RPCResult{RPCStatus: *status}, nil
+			}
+		}
+		if time.Now().Add(interval).After(deadline) {
+			if attempted && status.OutOfSync {
+				return &RPCResult{RPCStatus: *status}, nil
+			}
+			return nil, fmt.Errorf("timed out waiting for the password change of the secret with id '%d'", id)
+		}
+		log.Printf("[DEBUG] password change of secret '%d' has not completed, waiting %s", id, interval)
+		time.Sleep(interval)
+	}
+}
+
+// passwordChangedSince reports whether the password history of the secret
+// with the given id has an entry dated at or after since, a time reported by
+// the server
+func (s Server) passwordChangedSince(id int, since time.Time) (bool, error) {
+	history, err := s.SecretPasswordHistory(id)
+	if err != nil {
+		return false, err
+	}
+	for _, entry := range history {
+		if !entry.Date.Before(since) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// secretSummary gets the summary of the secret with the given id
+func (s Server) secretSummary(id int) (*secretSummary, error) {
+	summary := new(secretSummary)
+	path := fmt.Sprintf("%d/summary", id)
+
+	if data, err := s.accessResource("GET", resource, path, nil); err == nil {
+		if err = json.Unmarshal(data, summary); err != nil {
+			log.Printf("[ERROR] error parsing response from /%s/%s: %q", resource, path, data)
+			return nil, err
+		}
+	} else {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// generateSecretPassword generates a password for the first password field of
+// the template of the secret with the given id
+func (s Server) generateSecretPassword(id int) (string, error) {
+	secret, err := s.secretWithoutFiles(id, LookupOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	template, err := s.SecretTemplate(secret.SecretTemplateID)
+	if err != nil {
+		return "", err
+	}
+	for _, field := range template.Fields {
+		if field.IsPassword {
+			return s.GeneratePassword(field.FieldSlugName, template)
+		}
+	}
+	return "", fmt.Errorf("the template with id '%d' has no password field", template.ID)
+}
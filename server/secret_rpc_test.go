@@ -0,0 +1,188 @@
+package server_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DelineaXPM/tss-sdk-go/v3/server"
+	"github.com/DelineaXPM/tss-sdk-go/v3/tsstest"
+)
+
+// wait polls the fake often, for as long as a test should take
+var wait = server.WaitOptions{Timeout: time.Second, PollInterval: time.Millisecond}
+
+// rpcStatus returns the RPC status of the secret before a password change
+func rpcStatus(t *testing.T, tss *server.Server, id int) *server.RPCStatus {
+	status, err := tss.RPCStatus(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return status
+}
+
+// TestWaitForPasswordChange tests remote password changes against the fake,
+// which keeps them in progress for a few polls
+func TestWaitForPasswordChange(t *testing.T) {
+	fake, template, tss := newFakeServer(t)
+	defer fake.Close()
+
+	t.Run("succeeded", func(t *testing.T) {
+		secret := addPasswordSecret(t, fake, template, "Succeeds")
+		fake.SetRemote(secret.ID, tsstest.Remote{Polls: 3})
+
+		before := rpcStatus(t, tss, secret.ID)
+		if err := tss.ChangePassword(secret.ID, "n3w-Passw0rd"); err != nil {
+			t.Fatal(err)
+		}
+		result, err := tss.WaitForPasswordChange(secret.ID, before, wait)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stored, _ := fake.Secret(secret.ID)
+		if password, _ := stored.Field("password"); !result.Succeeded || password != "n3w-Passw0rd" {
+			t.Errorf("expected the change to have completed, found %+v and password %q", result, password)
+		}
+	})
+
+	t.Run("generated password", func(t *testing.T) {
+		secret := addPasswordSecret(t, fake, template, "Generated")
+
+		before := rpcStatus(t, tss, secret.ID)
+		if err := tss.ChangePassword(secret.ID, ""); err != nil {
+			t.Fatal(err)
+		}
+		if result, err := tss.WaitForPasswordChange(secret.ID, before, wait); err != nil || !result.Succeeded {
+			t.Fatalf("expected the change to succeed, found %+v (%v)", result, err)
+		}
+		stored, _ := fake.Secret(secret.ID)
+		if password, _ := stored.Field("password"); password == "s3cret" || password == "" {
+			t.Errorf("expected a generated password, found %q", password)
+		}
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		secret := addPasswordSecret(t, fake, template, "Fails")
+		fake.SetRemote(secret.ID, tsstest.Remote{Polls: 2, Failure: "Access is denied"})
+
+		before := rpcStatus(t, tss, secret.ID)
+		if err := tss.ChangePassword(secret.ID, "n3w-Passw0rd"); err != nil {
+			t.Fatal(err)
+		}
+		result, err := tss.WaitForPasswordChange(secret.ID, before, wait)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Succeeded || !result.OutOfSync || result.OutOfSyncReason != "Access is denied" {
+			t.Errorf("expected the change to have failed, found %+v", result)
+		}
+		stored, _ := fake.Secret(secret.ID)
+		if password, _ := stored.Field("password"); password != "s3cret" {
+			t.Errorf("expected the password to be kept, found %q", password)
+		}
+	})
+
+	t.Run("retried while out of sync", func(t *testing.T) {
+		secret := addPasswordSecret(t, fake, template, "Retried")
+		fake.SetRemote(secret.ID, tsstest.Remote{Polls: 1, Failure: "Access is denied"})
+		before := rpcStatus(t, tss, secret.ID)
+		if err := tss.ChangePassword(secret.ID, "n3w-Passw0rd"); err != nil {
+			t.Fatal(err)
+		}
+		if result, err := tss.WaitForPasswordChange(secret.ID, before, wait); err != nil || result.Succeeded {
+			t.Fatalf("expected the first change to fail, found %+v (%v)", result, err)
+		}
+
+		fake.SetRemote(secret.ID, tsstest.Remote{Polls: 3})
+		before = rpcStatus(t, tss, secret.ID)
+		if err := tss.ChangePassword(secret.ID, "n3w-Passw0rd"); err != nil {
+			t.Fatal(err)
+		}
+		if result, err := tss.WaitForPasswordChange(secret.ID, before, wait); err != nil || !result.Succeeded {
+			t.Errorf("expected the retry to succeed, found %+v (%v)", result, err)
+		}
+	})
+
+	t.Run("timed out", func(t *testing.T) {
+		secret := addPasswordSecret(t, fake, template, "Slow")
+		fake.SetRemote(secret.ID, tsstest.Remote{Polls: 1000})
+
+		before := rpcStatus(t, tss, secret.ID)
+		if err := tss.ChangePassword(secret.ID, "n3w-Passw0rd"); err != nil {
+			t.Fatal(err)
+		}
+		timeout := server.WaitOptions{Timeout: 20 * time.Millisecond, PollInterval: time.Millisecond}
+		if result, err := tss.WaitForPasswordChange(secret.ID, before, timeout); err == nil {
+			t.Errorf("expected a change in progress to time out, found %+v", result)
+		}
+	})
+
+	t.Run("clock skew", func(t *testing.T) {
+		defer fake.SetClockSkew(0)
+		for _, skew := range []time.Duration{-time.Hour, time.Hour} {
+			fake.SetClockSkew(skew)
+			secret := addPasswordSecret(t, fake, template, "Skewed "+skew.String())
+			fake.SetRemote(secret.ID, tsstest.Remote{Polls: 2})
+
+			before := rpcStatus(t, tss, secret.ID)
+			if err := tss.ChangePassword(secret.ID, "n3w-Passw0rd"); err != nil {
+				t.Fatal(err)
+			}
+			if result, err := tss.WaitForPasswordChange(secret.ID, before, wait); err != nil || !result.Succeeded {
+				t.Errorf("expected the change to succeed with the server clock off by %s, found %+v (%v)", skew, result, err)
+			}
+		}
+	})
+
+	t.Run("no such secret", func(t *testing.T) {
+		if err := tss.ChangePassword(1000, "n3w-Passw0rd"); err == nil {
+			t.Error("expected an error changing the password of a secret that does not exist")
+		}
+		if _, err := tss.RPCStatus(1000); err == nil {
+			t.Error("expected an error reading the status of a secret that does not exist")
+		}
+	})
+}
+
+// TestWaitForHeartbeat tests heartbeats against the fake
+func TestWaitForHeartbeat(t *testing.T) {
+	fake, template, tss := newFakeServer(t)
+	defer fake.Close()
+
+	defer fake.SetClockSkew(0)
+	for _, test := range []struct {
+		failure string
+		skew    time.Duration
+	}{
+		{"", 0},
+		{"Unable to connect", 0},
+		{"", -time.Hour},
+	} {
+		fake.SetClockSkew(test.skew)
+		secret := addPasswordSecret(t, fake, template, "Heartbeat "+test.failure)
+		fake.SetRemote(secret.ID, tsstest.Remote{Polls: 2, Failure: test.failure})
+
+		// the secret has been heartbeat before
+		if err := tss.Heartbeat(secret.ID); err != nil {
+			t.Fatal(err)
+		}
+		previous, err := tss.WaitForHeartbeat(secret.ID, nil, wait)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := tss.Heartbeat(secret.ID); err != nil {
+			t.Fatal(err)
+		}
+		status, err := tss.WaitForHeartbeat(secret.ID, previous, wait)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !status.Complete() || status.Succeeded() != (test.failure == "") || !status.CheckedAt.After(previous.CheckedAt) {
+			t.Errorf("expected the new heartbeat to complete with failure %q, found %+v", test.failure, status)
+		}
+	}
+
+	if err := tss.Heartbeat(1000); err == nil {
+		t.Error("expected an error heartbeating a secret that does not exist")
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"net/url"
 	"strconv"
+	"strings"
 )
 
 // templateResource is the HTTP URL path component for the secret templates resource
@@ -14,6 +16,7 @@ const templateResource = "secret-templates"
 type SecretTemplate struct {
 	Name   string
 	ID     int
+	Active bool
 	Fields []SecretTemplateField
 }
 
@@ -22,6 +25,15 @@ type SecretTemplateField struct {
 	SecretTemplateFieldID                                   int
 	FieldSlugName, DisplayName, Description, Name, ListType string
 	IsFile, IsList, IsNotes, IsPassword, IsRequired, IsUrl  bool
+	IsActive, IsExpirationField, IsIndexable, MustEncrypt   bool
+	HideOnView                                              bool
+	HistoryLength, PasswordRequirementID, SortOrder         int `json:",omitempty"`
+}
+
+// SecretTemplateFilter restricts the secret templates returned by SecretTemplates
+type SecretTemplateFilter struct {
+	SearchText      string
+	IncludeInactive bool
 }
 
 // SecretTemplate gets the secret template with id from the Secret Server of the given tenant
@@ -40,6 +52,112 @@ func (s Server) SecretTemplate(id int) (*SecretTemplate, error) {
 	return secretTemplate, nil
 }
 
+// SecretTemplates gets the secret templates whose names contain the search
+// text of the given filter. The templates are summaries; their Fields are not
+// populated. Use SecretTemplate to get the fields of a template.
+func (s Server) SecretTemplates(filter SecretTemplateFilter) ([]SecretTemplate, error) {
+	templates := make([]SecretTemplate, 0)
+	query := fmt.Sprintf("filter.searchText=%s&filter.includeInactive=%t",
+		url.QueryEscape(filter.SearchText), filter.IncludeInactive)
+
+	err := s.listAll(templateResource, "", query, func(data json.RawMessage) error {
+		var page []SecretTemplate
+		if err := json.Unmarshal(data, &page); err != nil {
+			return err
+		}
+		templates = append(templates, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// SecretTemplateByName gets the secret template with the given name, and a
+// boolean indicating whether there is such a template
+func (s Server) SecretTemplateByName(name string) (*SecretTemplate, bool, error) {
+	templates, err := s.SecretTemplates(SecretTemplateFilter{SearchText: name, IncludeInactive: true})
+	if err != nil {
+		return nil, false, err
+	}
+	for _, template := range templates {
+		if strings.EqualFold(template.Name, name) {
+			secretTemplate, err := s.SecretTemplate(template.ID)
+			if err != nil {
+				return nil, false, err
+			}
+			return secretTemplate, true, nil
+		}
+	}
+	log.Printf("[DEBUG] no secret template named '%s'", name)
+	return nil, false, nil
+}
+
+// CreateSecretTemplate creates the given secret template, along with its fields
+func (s Server) CreateSecretTemplate(template SecretTemplate) (*SecretTemplate, error) {
+	template.ID = 0
+	created := new(SecretTemplate)
+
+	if data, err := s.accessResource("POST", templateResource, "/", template); err == nil {
+		if err = json.Unmarshal(data, created); err != nil {
+			log.Printf("[ERROR] error parsing response from /%s: %q", templateResource, data)
+			return nil, err
+		}
+	} else {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// AddSecretTemplateField adds the given field to the secret template with the
+// given id
+func (s Server) AddSecretTemplateField(templateID int, field SecretTemplateField) (*SecretTemplateField, error) {
+	field.SecretTemplateFieldID = 0
+	return s.writeSecretTemplateField("POST", fmt.Sprintf("%d/fields", templateID), field)
+}
+
+// UpdateSecretTemplateField updates the given field of the secret template
+// with the given id
+func (s Server) UpdateSecretTemplateField(templateID int, field SecretTemplateField) (*SecretTemplateField, error) {
+	path := fmt.Sprintf("%d/fields/%d", templateID, field.SecretTemplateFieldID)
+	return s.writeSecretTemplateField("PUT", path, field)
+}
+
+// DisableSecretTemplateField deactivates the field identified by the given
+// slug on the secret template with the given id. Secret Server does not
+// delete template fields, since existing secrets may still hold values for
+// them.
+func (s Server) DisableSecretTemplateField(templateID int, slug string) error {
+	template, err := s.SecretTemplate(templateID)
+	if err != nil {
+		return err
+	}
+	field, found := template.GetField(slug)
+	if !found {
+		return fmt.Errorf("field name '%s' is not defined on the secret template with id '%d'", slug, templateID)
+	}
+	field.IsActive = false
+	_, err = s.UpdateSecretTemplateField(templateID, *field)
+	return err
+}
+
+func (s Server) writeSecretTemplateField(method, path string, field SecretTemplateField) (*SecretTemplateField, error) {
+	written := new(SecretTemplateField)
+
+	if data, err := s.accessResource(method, templateResource, path, field); err == nil {
+		if err = json.Unmarshal(data, written); err != nil {
+			log.Printf("[ERROR] error parsing response from /%s/%s: %q", templateResource, path, data)
+			return nil, err
+		}
+	} else {
+		return nil, err
+	}
+
+	return written, nil
+}
+
 // GeneratePassword generates and returns a password for the secret field identified by the given slug on the given
 // template. The password adheres to the password requirements associated with the field. NOTE: this should only be
 // used with fields whose IsPassword property is true.
@@ -0,0 +1,68 @@
+package server_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/DelineaXPM/tss-sdk-go/v3/server"
+)
+
+// TestSecretTemplateCRUD tests creating, finding and changing the fields of
+// secret templates against the fake
+func TestSecretTemplateCRUD(t *testing.T) {
+	fake, _, tss := newFakeServer(t)
+	defer fake.Close()
+	for i := 0; i < 40; i++ {
+		fake.AddTemplate(server.SecretTemplate{Name: fmt.Sprintf("Generic %d", i)})
+	}
+
+	created, err := tss.CreateSecretTemplate(server.SecretTemplate{Name: "Service Account", Fields: []server.SecretTemplateField{
+		{Name: "Username", FieldSlugName: "username", IsRequired: true},
+		{Name: "Password", FieldSlugName: "password", IsPassword: true},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.ID == 0 || len(created.Fields) != 2 || created.Fields[1].SecretTemplateFieldID == 0 {
+		t.Fatalf("expected the created template with its fields, found %+v", created)
+	}
+	if _, err = tss.CreateSecretTemplate(server.SecretTemplate{Name: "service account"}); err == nil {
+		t.Error("expected an error creating a template with a name in use")
+	}
+
+	templates, err := tss.SecretTemplates(server.SecretTemplateFilter{SearchText: "generic"})
+	if err != nil || len(templates) != 40 {
+		t.Errorf("expected every matching template, over several pages, found %d (%v)", len(templates), err)
+	}
+
+	field, err := tss.AddSecretTemplateField(created.ID, server.SecretTemplateField{Name: "Notes", IsNotes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if field.FieldSlugName != "notes" || field.SecretTemplateFieldID == 0 {
+		t.Errorf("expected the added field, found %+v", field)
+	}
+	field.DisplayName = "Comments"
+	if field, err = tss.UpdateSecretTemplateField(created.ID, *field); err != nil || field.DisplayName != "Comments" {
+		t.Errorf("expected the updated field, found %+v (%v)", field, err)
+	}
+	if err = tss.DisableSecretTemplateField(created.ID, "notes"); err != nil {
+		t.Fatal(err)
+	}
+
+	template, found, err := tss.SecretTemplateByName("SERVICE ACCOUNT")
+	if err != nil || !found {
+		t.Fatalf("expected to find the template by name, found %v (%v)", found, err)
+	}
+	notes, _ := template.GetField("notes")
+	if len(template.Fields) != 3 || notes == nil || notes.IsActive || notes.DisplayName != "Comments" {
+		t.Errorf("expected the template with its disabled field, found %+v", template)
+	}
+
+	if err = tss.DisableSecretTemplateField(created.ID, "no-such-field"); err == nil {
+		t.Error("expected an error disabling a field that does not exist")
+	}
+	if _, err = tss.AddSecretTemplateField(1000, server.SecretTemplateField{Name: "Notes"}); err == nil {
+		t.Error("expected an error adding a field to a template that does not exist")
+	}
+}
@@ -8,26 +8,28 @@ import (
 // "Test #6" in the README.
 func TestSecretTemplate(t *testing.T) {
 	t.Run("SecretServer_TestSecretTemplate", func(t *testing.T) {
-		tss, err := initServer()
+		tss, err := initServer(t)
 		if err != nil {
 			t.Error("configuring the Server:", err)
 			return
 		}
+		defer tss.finish(t)
 		VerifySecretTemplate(t, tss)
 	})
 
 	t.Run("Platform_TestSecretTemplate", func(t *testing.T) {
-		tss, err := initPlatformServer()
+		tss, err := initPlatformServer(t)
 		if err != nil {
 			t.Error("configuring the Platform Server:", err)
 			return
 		}
+		defer tss.finish(t)
 		VerifySecretTemplate(t, tss)
 	})
 }
 
-func VerifySecretTemplate(t *testing.T, tss *Server) {
-	id := initIntegerFromEnv("TSS_TEMPLATE_ID", t)
+func VerifySecretTemplate(t *testing.T, tss *testServer) {
+	id := tss.initIntegerFromEnv("TSS_TEMPLATE_ID", t)
 	if id < 0 {
 		return
 	}
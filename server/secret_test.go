@@ -12,26 +12,28 @@ import (
 // TestSecret tests Secret. Referred to as "Test #1" in the README.
 func TestSecret(t *testing.T) {
 	t.Run("SecretServer_TestSecret", func(t *testing.T) {
-		tss, err := initServer()
+		tss, err := initServer(t)
 		if err != nil {
 			t.Error("configuring the Server:", err)
 			return
 		}
+		defer tss.finish(t)
 		GetSecret(t, tss)
 	})
 
 	t.Run("Platform_TestSecret", func(t *testing.T) {
-		tss, err := initPlatformServer()
+		tss, err := initPlatformServer(t)
 		if err != nil {
 			t.Error("configuring the Platform Server:", err)
 			return
 		}
+		defer tss.finish(t)
 		GetSecret(t, tss)
 	})
 }
 
-func GetSecret(t *testing.T, tss *Server) {
-	id := initIntegerFromEnv("TSS_SECRET_ID", t)
+func GetSecret(t *testing.T, tss *testServer) {
+	id := tss.initIntegerFromEnv("TSS_SECRET_ID", t)
 	if id < 0 {
 		return
 	}
@@ -60,29 +62,31 @@ func GetSecret(t *testing.T, tss *Server) {
 // Referred to as "Test #2" in the README.
 func TestSecretCRUD(t *testing.T) {
 	t.Run("SecretServer_TestSecretCRUD", func(t *testing.T) {
-		tss, err := initServer()
+		tss, err := initServer(t)
 		if err != nil {
 			t.Error("configuring the Server:", err)
 			return
 		}
+		defer tss.finish(t)
 		SecretCRUD(t, tss)
 	})
 
 	t.Run("Platform_TestSecretCRUD", func(t *testing.T) {
-		tss, err := initPlatformServer()
+		tss, err := initPlatformServer(t)
 		if err != nil {
 			t.Error("configuring the Platform Server:", err)
 			return
 		}
+		defer tss.finish(t)
 		SecretCRUD(t, tss)
 	})
 }
 
-func SecretCRUD(t *testing.T, tss *Server) {
-	siteId := initIntegerFromEnv("TSS_SITE_ID", t)
-	folderId := initIntegerFromEnv("TSS_FOLDER_ID", t)
-	templateId := initIntegerFromEnv("TSS_TEMPLATE_ID", t)
-	testPassword := os.Getenv("TSS_TEST_PASSWORD")
+func SecretCRUD(t *testing.T, tss *testServer) {
+	siteId := tss.initIntegerFromEnv("TSS_SITE_ID", t)
+	folderId := tss.initIntegerFromEnv("TSS_FOLDER_ID", t)
+	templateId := tss.initIntegerFromEnv("TSS_TEMPLATE_ID", t)
+	testPassword := tss.getenv("TSS_TEST_PASSWORD")
 
 	if testPassword == "" {
 		t.Error("testPassword is blank")
@@ -233,29 +237,31 @@ func SecretCRUD(t *testing.T, tss *Server) {
 // mappings that support SSH keys. Referred to as "Test #3" in the README.
 func TestSecretCRUDForSSHTemplate(t *testing.T) {
 	t.Run("SecretServer_TestSecretCRUDForSSHTemplate", func(t *testing.T) {
-		tss, err := initServer()
+		tss, err := initServer(t)
 		if err != nil {
 			t.Error("configuring the Server:", err)
 			return
 		}
+		defer tss.finish(t)
 		SecretCRUDForSSHTemplate(t, tss)
 	})
 
 	t.Run("Platform_TestSecretCRUDForSSHTemplate", func(t *testing.T) {
-		tss, err := initPlatformServer()
+		tss, err := initPlatformServer(t)
 		if err != nil {
 			t.Error("configuring the Platform Server:", err)
 			return
 		}
+		defer tss.finish(t)
 		SecretCRUDForSSHTemplate(t, tss)
 	})
 }
 
-func SecretCRUDForSSHTemplate(t *testing.T, tss *Server) {
-	siteId := initIntegerFromEnv("TSS_SITE_ID", t)
-	folderId := initIntegerFromEnv("TSS_FOLDER_ID", t)
-	templateId := initIntegerFromEnv("TSS_SSH_KEY_TEMPLATE_ID", t)
-	testPassword := os.Getenv("TSS_TEST_PASSWORD")
+func SecretCRUDForSSHTemplate(t *testing.T, tss *testServer) {
+	siteId := tss.initIntegerFromEnv("TSS_SITE_ID", t)
+	folderId := tss.initIntegerFromEnv("TSS_FOLDER_ID", t)
+	templateId := tss.initIntegerFromEnv("TSS_SSH_KEY_TEMPLATE_ID", t)
+	testPassword := tss.getenv("TSS_TEST_PASSWORD")
 	if siteId < 0 || folderId < 0 || templateId < 0 {
 		return
 	}
@@ -613,27 +619,29 @@ func SecretCRUDForSSHTemplate(t *testing.T, tss *Server) {
 // TestSearch tests Secret. Referred to as "Test #4" in the README.
 func TestSearch(t *testing.T) {
 	t.Run("SecretServer_TestSearch", func(t *testing.T) {
-		tss, err := initServer()
+		tss, err := initServer(t)
 		if err != nil {
 			t.Error("configuring the Server:", err)
 			return
 		}
+		defer tss.finish(t)
 		Search(t, tss)
 	})
 
 	t.Run("Platform_TestSearch", func(t *testing.T) {
-		tss, err := initPlatformServer()
+		tss, err := initPlatformServer(t)
 		if err != nil {
 			t.Error("configuring the Platform Server:", err)
 			return
 		}
+		defer tss.finish(t)
 		Search(t, tss)
 	})
 }
 
-func Search(t *testing.T, tss *Server) {
+func Search(t *testing.T, tss *testServer) {
 
-	s, err := tss.Secrets(os.Getenv("TSS_SEARCH_TEXT"), os.Getenv("TSS_SEARCH_FIELD"))
+	s, err := tss.Secrets(tss.getenv("TSS_SEARCH_TEXT"), tss.getenv("TSS_SEARCH_FIELD"))
 
 	if err != nil {
 		t.Error("calling server.Secret:", err)
@@ -653,27 +661,29 @@ func Search(t *testing.T, tss *Server) {
 // TestSearchWithoutField tests Secret. Referred to as "Test #5" in the README.
 func TestSearchWithoutField(t *testing.T) {
 	t.Run("SecretServer_TestSearchWithoutField", func(t *testing.T) {
-		tss, err := initServer()
+		tss, err := initServer(t)
 		if err != nil {
 			t.Error("configuring the Server:", err)
 			return
 		}
+		defer tss.finish(t)
 		SearchWithoutField(t, tss)
 	})
 
 	t.Run("Platform_TestSearchWithoutField", func(t *testing.T) {
-		tss, err := initPlatformServer()
+		tss, err := initPlatformServer(t)
 		if err != nil {
 			t.Error("configuring the Platform Server:", err)
 			return
 		}
+		defer tss.finish(t)
 		SearchWithoutField(t, tss)
 	})
 }
 
-func SearchWithoutField(t *testing.T, tss *Server) {
+func SearchWithoutField(t *testing.T, tss *testServer) {
 
-	s, err := tss.Secrets(os.Getenv("TSS_SEARCH_TEXT"), "")
+	s, err := tss.Secrets(tss.getenv("TSS_SEARCH_TEXT"), "")
 
 	if err != nil {
 		t.Error("calling server.Secret:", err)
@@ -693,26 +703,28 @@ func SearchWithoutField(t *testing.T, tss *Server) {
 // TestSecretByPath tests Secret. Referred to as "Test #7" in the README.
 func TestSecretByPath(t *testing.T) {
 	t.Run("SecretServer_TestSecretByPath", func(t *testing.T) {
-		tss, err := initServer()
+		tss, err := initServer(t)
 		if err != nil {
 			t.Error("configuring the Server:", err)
 			return
 		}
+		defer tss.finish(t)
 		GetSecretByPath(t, tss)
 	})
 
 	t.Run("Platform_TestSecretByPath", func(t *testing.T) {
-		tss, err := initPlatformServer()
+		tss, err := initPlatformServer(t)
 		if err != nil {
 			t.Error("configuring the Platform Server:", err)
 			return
 		}
+		defer tss.finish(t)
 		GetSecretByPath(t, tss)
 	})
 }
 
-func GetSecretByPath(t *testing.T, tss *Server) {
-	secretPath := initStringFromEnv("TSS_SECRET_PATH", t)
+func GetSecretByPath(t *testing.T, tss *testServer) {
+	secretPath := tss.initStringFromEnv("TSS_SECRET_PATH", t)
 	if secretPath == "" {
 		t.Error("TSS_SECRET_PATH is not set or empty")
 		return
@@ -741,51 +753,42 @@ func GetSecretByPath(t *testing.T, tss *Server) {
 	}
 }
 
-func initServer() (*Server, error) {
-	var config *Configuration
+func initServer(t *testing.T) (*testServer, error) {
+	return initTestServer(t, EnvServerURL, func() (*Configuration, error) {
+		if cj, err := ioutil.ReadFile("../test_config.json"); err == nil {
+			config := new(Configuration)
 
-	if cj, err := ioutil.ReadFile("../test_config.json"); err == nil {
-		config = new(Configuration)
-
-		json.Unmarshal(cj, &config)
-	} else {
-		config = &Configuration{
-			Credentials: UserCredential{
-				Username: os.Getenv("TSS_USERNAME"),
-				Password: os.Getenv("TSS_PASSWORD"),
-			},
-			// Expecting either the tenant or URL to be set
-			Tenant:    os.Getenv("TSS_TENANT"),
-			ServerURL: os.Getenv("TSS_SERVER_URL"),
+			json.Unmarshal(cj, &config)
+			return config, nil
 		}
-	}
-	return New(*config)
+		// Expecting either the tenant or URL to be set
+		return ConfigurationFromEnv()
+	})
 }
 
-func initPlatformServer() (*Server, error) {
-	var config *Configuration
+func initPlatformServer(t *testing.T) (*testServer, error) {
+	return initTestServer(t, "TSS_PLATFORM_URL", func() (*Configuration, error) {
+		if cj, err := ioutil.ReadFile("../test_config.json"); err == nil {
+			config := new(Configuration)
 
-	if cj, err := ioutil.ReadFile("../test_config.json"); err == nil {
-		config = new(Configuration)
-
-		json.Unmarshal(cj, &config)
-	} else {
-		config = &Configuration{
+			json.Unmarshal(cj, &config)
+			return config, nil
+		}
+		return &Configuration{
 			Credentials: UserCredential{
 				Username: os.Getenv("TSS_PLATFORM_USERNAME"),
 				Password: os.Getenv("TSS_PLATFORM_PASSWORD"),
 			},
 			ServerURL: os.Getenv("TSS_PLATFORM_URL"),
-		}
-	}
-	return New(*config)
+		}, nil
+	})
 }
 
 // initIntegerFromEnv reads the given environment variable and if it's declared, parses it to an integer. Otherwise,
 // returns a default integer of '1'.
-func initIntegerFromEnv(envVarName string, t *testing.T) int {
+func (s *testServer) initIntegerFromEnv(envVarName string, t *testing.T) int {
 	intValue := 1
-	valueFromEnv := os.Getenv(envVarName)
+	valueFromEnv := s.getenv(envVarName)
 	if valueFromEnv != "" {
 		var err error
 		intValue, err = strconv.Atoi(valueFromEnv)
@@ -799,9 +802,9 @@ func initIntegerFromEnv(envVarName string, t *testing.T) int {
 
 // initStringFromEnv reads a string value from the given environment variable.
 // Otherwise returns a default string '/Personal/admin/New Secret'.
-func initStringFromEnv(envVarName string, t *testing.T) string {
+func (s *testServer) initStringFromEnv(envVarName string, t *testing.T) string {
 	defaultValue := "/Personal/admin/New Secret"
-	value := os.Getenv(envVarName)
+	value := s.getenv(envVarName)
 	if value == "" {
 		t.Logf("%s not set, using default: %s", envVarName, defaultValue)
 		return defaultValue
@@ -36,11 +36,17 @@ type Configuration struct {
 	Credentials                                      UserCredential
 	ServerURL, TLD, Tenant, apiPathURI, tokenPathURI string
 	TLSClientConfig                                  *tls.Config
+	RateLimit                                        *RateLimit
+	// Transport, if set, sends the requests to the server instead of
+	// http.DefaultTransport, for example to record or replay them in tests.
+	// TLSClientConfig is ignored when it is set.
+	Transport http.RoundTripper `json:"-"`
 }
 
 // Server provides access to secrets stored in Delinea Secret Server
 type Server struct {
 	Configuration
+	limiter *rateLimiter
 }
 
 type TokenCache struct {
@@ -48,16 +54,22 @@ type TokenCache struct {
 	ExpiresIn   int    `json:"expires_in"`
 }
 
-// New returns an initialized Secrets object
+// New returns an initialized Secrets object. The configuration must set
+// either ServerURL or Tenant, and either an access token or a username and
+// password.
 func New(config Configuration) (*Server, error) {
-	if config.ServerURL == "" && config.Tenant == "" || config.ServerURL != "" && config.Tenant != "" {
-		return nil, fmt.Errorf("either ServerURL of Secret Server/Platform or Tenant of Secret Server Cloud must be set")
+	if err := config.validate(); err != nil {
+		return nil, err
 	}
 	if config.TLD == "" {
 		config.TLD = defaultTLD
 	}
-	if config.TLSClientConfig != nil {
-		http.DefaultTransport.(*http.Transport).TLSClientConfig = config.TLSClientConfig
+	if config.TLSClientConfig != nil && config.Transport == nil {
+		// a copy of the default transport, since changing it would change the
+		// TLS configuration of every client in the process
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = config.TLSClientConfig
+		config.Transport = transport
 	}
 	if config.apiPathURI == "" {
 		config.apiPathURI = defaultAPIPathURI
@@ -67,7 +79,7 @@ func New(config Configuration) (*Server, error) {
 		config.tokenPathURI = defaultTokenPathURI
 	}
 	config.tokenPathURI = strings.Trim(config.tokenPathURI, "/")
-	return &Server{config}, nil
+	return &Server{Configuration: config, limiter: newRateLimiter(config.RateLimit)}, nil
 }
 
 // urlFor is the URL for the given resource and path
@@ -94,7 +106,7 @@ func (s Server) urlFor(resource, path string) string {
 	}
 }
 
-func (s Server) urlForSearch(resource, searchText, fieldName string) string {
+func (s Server) urlForSearch(resource, searchText, fieldName string, includeInactive bool) string {
 	var baseURL string
 
 	if s.ServerURL == "" {
@@ -110,6 +122,9 @@ func (s Server) urlForSearch(resource, searchText, fieldName string) string {
 			strings.Trim(resource, "/"),
 			searchText,
 			fieldName)
+		if includeInactive {
+			url = fmt.Sprintf("%s%s", url, "&paging.filter.includeInactive=true")
+		}
 		if fieldName == "" {
 			return fmt.Sprintf("%s%s", url, "&paging.filter.extendedFields=Machine&paging.filter.extendedFields=Notes&paging.filter.extendedFields=Username")
 		}
@@ -125,6 +140,13 @@ func (s Server) accessResource(method, resource, path string, input interface{})
 	switch resource {
 	case "secrets":
 	case "secret-templates":
+	case "secret-permissions":
+	case "folder-permissions":
+	case "users":
+	case "groups":
+	case "roles":
+	case "secret-erase-requests":
+	case "secret-policy":
 	default:
 		message := "unknown resource"
 
@@ -166,10 +188,10 @@ func (s Server) accessResource(method, resource, path string, input interface{})
 
 	log.Printf("[DEBUG] calling %s %s", method, req.URL.String())
 
-	data, statusCode, err := handleResponse((&http.Client{}).Do(req))
+	data, statusCode, err := handleResponse(s.do(req))
 
 	// Check for unauthorized or access denied
-	if statusCode.StatusCode == http.StatusUnauthorized || statusCode.StatusCode == http.StatusForbidden {
+	if statusCode != nil && (statusCode.StatusCode == http.StatusUnauthorized || statusCode.StatusCode == http.StatusForbidden) {
 		s.clearTokenCache()
 		log.Printf("[ERROR] Token cache cleared due to unauthorized or access denied response.")
 	}
@@ -180,7 +202,7 @@ func (s Server) accessResource(method, resource, path string, input interface{})
 // searchResources uses the accessToken to search for API resources.
 // It assumes an appropriate combination of resource, search text.
 // field is optional
-func (s Server) searchResources(resource, searchText, field string) ([]byte, error) {
+func (s Server) searchResources(resource, searchText, field string, includeInactive bool) ([]byte, error) {
 	switch resource {
 	case "secrets":
 	default:
@@ -200,7 +222,7 @@ func (s Server) searchResources(resource, searchText, field string) ([]byte, err
 		return nil, err
 	}
 
-	req, err := http.NewRequest(method, s.urlForSearch(resource, searchText, field), body)
+	req, err := http.NewRequest(method, s.urlForSearch(resource, searchText, field, includeInactive), body)
 
 	if err != nil {
 		log.Printf("[ERROR] creating req: %s /%s/%s/%s: %s", method, resource, searchText, field, err)
@@ -211,7 +233,7 @@ func (s Server) searchResources(resource, searchText, field string) ([]byte, err
 
 	log.Printf("[DEBUG] calling %s %s", method, req.URL.String())
 
-	data, _, err := handleResponse((&http.Client{}).Do(req))
+	data, _, err := handleResponse(s.do(req))
 
 	return data, err
 }
@@ -261,7 +283,7 @@ func (s Server) uploadFile(secretId int, fileField SecretField) error {
 	req.Header.Add("Authorization", "Bearer "+accessToken)
 	req.Header.Set("Content-Type", multipartWriter.FormDataContentType())
 	log.Printf("[DEBUG] uploading file with PUT %s", req.URL.String())
-	_, _, err = handleResponse((&http.Client{}).Do(req))
+	_, _, err = handleResponse(s.do(req))
 
 	return err
 }
@@ -340,7 +362,13 @@ func (s *Server) getAccessToken() (string, error) {
 
 		body := strings.NewReader(values.Encode())
 		requestUrl := s.urlFor("token", "")
-		data, _, err := handleResponse(http.Post(requestUrl, "application/x-www-form-urlencoded", body))
+		req, err := http.NewRequest("POST", requestUrl, body)
+		if err != nil {
+			log.Print("Error creating HTTP request:", err)
+			return "", err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		data, _, err := handleResponse(s.do(req))
 
 		if err != nil {
 			log.Print("[ERROR] grant response error:", err)
@@ -372,11 +400,11 @@ func (s *Server) checkPlatformDetails(baseURL string) (string, error) {
 	platformHelthCheckUrl := fmt.Sprintf("%s/%s", strings.Trim(baseURL, "/"), "health")
 	ssHealthCheckUrl := fmt.Sprintf("%s/%s", strings.Trim(baseURL, "/"), "api/v1/healthcheck")
 
-	isHealthy := checkJSONResponse(ssHealthCheckUrl)
+	isHealthy := s.checkJSONResponse(ssHealthCheckUrl)
 	if isHealthy {
 		return "", nil
 	} else {
-		isHealthy := checkJSONResponse(platformHelthCheckUrl)
+		isHealthy := s.checkJSONResponse(platformHelthCheckUrl)
 		if isHealthy {
 
 			accessToken, found := s.getCacheAccessToken(baseURL)
@@ -395,7 +423,7 @@ func (s *Server) checkPlatformDetails(baseURL string) (string, error) {
 
 				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-				data, _, err := handleResponse((&http.Client{}).Do(req))
+				data, _, err := handleResponse(s.do(req))
 				if err != nil {
 					log.Print("[ERROR] get token response error:", err)
 					return "", err
@@ -421,7 +449,7 @@ func (s *Server) checkPlatformDetails(baseURL string) (string, error) {
 			}
 			req.Header.Add("Authorization", "Bearer "+accessToken)
 
-			data, _, err := handleResponse((&http.Client{}).Do(req))
+			data, _, err := handleResponse(s.do(req))
 			if err != nil {
 				log.Print("[ERROR] get vaults response error:", err)
 				return "", err
@@ -452,8 +480,13 @@ func (s *Server) checkPlatformDetails(baseURL string) (string, error) {
 	return "", fmt.Errorf("invalid URL")
 }
 
-func checkJSONResponse(url string) bool {
-	response, err := http.Get(url)
+func (s *Server) checkJSONResponse(url string) bool {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		log.Println("Error creating GET request:", err)
+		return false
+	}
+	response, err := s.do(req)
 	if err != nil {
 		log.Println("Error making GET request:", err)
 		return false
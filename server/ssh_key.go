@@ -0,0 +1,170 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+)
+
+// Formats in which the server can generate SSH private keys
+const (
+	SshKeyFormatOpenSSH = "OpenSSH"
+	SshKeyFormatPEM     = "PEM"
+)
+
+// SshKeyPair is an SSH key pair generated by the server
+type SshKeyPair struct {
+	PrivateKey, PublicKey, Passphrase string
+}
+
+// sshKeyGeneration is the request body for generating an SSH key pair
+type sshKeyGeneration struct {
+	SshKeyFormat       string `json:",omitempty"`
+	SshKeySize         int    `json:",omitempty"`
+	GeneratePassphrase bool
+}
+
+var (
+	privateKeyRegex = regexp.MustCompile("(?i)private")
+	publicKeyRegex  = regexp.MustCompile("(?i)public")
+	passphraseRegex = regexp.MustCompile("(?i)passphrase")
+)
+
+// SshKeyRotationError is returned when the rotation of the SSH keys of a
+// secret fails after some of its fields were replaced. It carries the
+// generated key pair, so that the caller can finish the rotation rather than
+// be left with a key whose other half, or passphrase, is lost.
+type SshKeyRotationError struct {
+	SecretID int
+	// Replaced holds the slugs of the fields that were replaced
+	Replaced []string
+	KeyPair  SshKeyPair
+	Err      error
+}
+
+func (e *SshKeyRotationError) Error() string {
+	return fmt.Sprintf("rotating the SSH keys of the secret with id '%d' failed after replacing '%s': %s",
+		e.SecretID, strings.Join(e.Replaced, "', '"), e.Err)
+}
+
+func (e *SshKeyRotationError) Unwrap() error {
+	return e.Err
+}
+
+// RotateSshKeys generates a new SSH key pair, and optionally a new private key
+// passphrase, for the existing secret with the given id, and stores them in
+// the private and public key fields of the secret. It returns the new public
+// key so that it can be distributed to the hosts the key is used for.
+//
+// The fields are identified by their slugs, which must contain "private",
+// "public" and "passphrase" respectively, as they do on the SSH templates
+// that ship with Secret Server. When generatePassphrase is false, the
+// passphrase field, if the template has one, is cleared because the new
+// private key is not encrypted.
+//
+// The server stores each field in a separate request, so the public key is
+// written first, then the passphrase, then the private key, which is never
+// stored without its passphrase. If a request fails after a field was
+// replaced, the error is an *SshKeyRotationError holding the key pair.
+func (s Server) RotateSshKeys(secretID int, generatePassphrase bool) (string, error) {
+	secret, err := s.secretWithoutFiles(secretID, LookupOptions{})
+	if err != nil {
+		return "", err
+	}
+	template, err := s.SecretTemplate(secret.SecretTemplateID)
+	if err != nil {
+		return "", err
+	}
+
+	var privateKeyField, publicKeyField, passphraseField *SecretTemplateField
+	for i, field := range template.Fields {
+		switch {
+		case field.IsFile && privateKeyRegex.MatchString(field.FieldSlugName):
+			privateKeyField = &template.Fields[i]
+		case field.IsFile && publicKeyRegex.MatchString(field.FieldSlugName):
+			publicKeyField = &template.Fields[i]
+		case field.IsPassword && passphraseRegex.MatchString(field.FieldSlugName):
+			passphraseField = &template.Fields[i]
+		}
+	}
+	if privateKeyField == nil || publicKeyField == nil {
+		return "", fmt.Errorf("the secret template with id '%d' does not have private and public key file fields", template.ID)
+	}
+	if generatePassphrase && passphraseField == nil {
+		return "", fmt.Errorf("the secret template with id '%d' does not have a passphrase field", template.ID)
+	}
+
+	keyPair, err := s.generateSshKeys(sshKeyGeneration{GeneratePassphrase: generatePassphrase})
+	if err != nil {
+		return "", err
+	}
+
+	filenames := make(map[string]string)
+	for _, field := range secret.Fields {
+		filenames[field.Slug] = field.Filename
+	}
+	var replaced []string
+	fail := func(err error) (string, error) {
+		if len(replaced) == 0 {
+			return "", err
+		}
+		log.Printf("[ERROR] rotating the SSH keys of secret '%d' failed after replacing %v: %s", secretID, replaced, err)
+		return "", &SshKeyRotationError{SecretID: secretID, Replaced: replaced, KeyPair: *keyPair, Err: err}
+	}
+	upload := func(slug, contents string) error {
+		filename := filenames[slug]
+		if filename == "" {
+			filename = slug
+		}
+		return s.uploadFile(secretID, SecretField{Slug: slug, Filename: filename, ItemValue: contents})
+	}
+
+	if err := upload(publicKeyField.FieldSlugName, keyPair.PublicKey); err != nil {
+		return fail(err)
+	}
+	replaced = append(replaced, publicKeyField.FieldSlugName)
+	if passphraseField != nil {
+		mod := fieldMod{Slug: passphraseField.FieldSlugName, Dirty: true, Value: keyPair.Passphrase}
+		if err := s.patchGeneral(secretID, fieldMods{SecretFields: []fieldMod{mod}}); err != nil {
+			return fail(err)
+		}
+		replaced = append(replaced, passphraseField.FieldSlugName)
+	}
+	if err := upload(privateKeyField.FieldSlugName, keyPair.PrivateKey); err != nil {
+		return fail(err)
+	}
+
+	log.Printf("[DEBUG] rotated the SSH keys of secret '%d'", secretID)
+	return keyPair.PublicKey, nil
+}
+
+// GenerateSshKeyPair generates and returns an SSH key pair, and optionally a
+// private key passphrase, without storing them in a secret. The format is one
+// of the SshKeyFormat constants, and keySize is the size of the key in bits;
+// either may be left empty or zero to use the server's default.
+func (s Server) GenerateSshKeyPair(format string, keySize int, generatePassphrase bool) (*SshKeyPair, error) {
+	return s.generateSshKeys(sshKeyGeneration{
+		SshKeyFormat:       format,
+		SshKeySize:         keySize,
+		GeneratePassphrase: generatePassphrase,
+	})
+}
+
+// generateSshKeys asks the server to generate an SSH key pair without storing
+// it in a secret
+func (s Server) generateSshKeys(args sshKeyGeneration) (*SshKeyPair, error) {
+	keyPair := new(SshKeyPair)
+	path := "generate-ssh-keys"
+
+	if data, err := s.accessResource("POST", resource, path, args); err == nil {
+		if err = json.Unmarshal(data, keyPair); err != nil {
+			log.Printf("[ERROR] error parsing response from /%s/%s: %q", resource, path, data)
+			return nil, err
+		}
+	} else {
+		return nil, err
+	}
+	return keyPair, nil
+}
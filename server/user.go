@@ -0,0 +1,188 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// userResource is the HTTP URL path component for the users resource
+const userResource = "users"
+
+// User represents a Secret Server user
+type User struct {
+	ID, DomainID                        int `json:",omitempty"`
+	UserName, DisplayName, EmailAddress string
+	Password                            string `json:",omitempty"`
+	Enabled, IsLockedOut, TwoFactor     bool
+}
+
+// Users provides the user administration operations of Secret Server
+type Users struct {
+	server Server
+}
+
+// Users returns the user administration operations, authenticated as this
+// Server
+func (s Server) Users() Users {
+	return Users{server: s}
+}
+
+// Get gets the user with the given id
+func (u Users) Get(id int) (*User, error) {
+	return u.request("GET", strconv.Itoa(id), nil)
+}
+
+// Search gets the users whose names contain the given text, including
+// disabled users
+func (u Users) Search(searchText string) ([]User, error) {
+	users := make([]User, 0)
+	query := fmt.Sprintf("filter.searchText=%s&filter.includeInactive=true", url.QueryEscape(searchText))
+
+	err := u.server.listAll(userResource, "", query, func(data json.RawMessage) error {
+		var page []User
+		if err := json.Unmarshal(data, &page); err != nil {
+			return err
+		}
+		users = append(users, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// ByUsername gets the user with the given username, and a boolean indicating
+// whether there is such a user
+func (u Users) ByUsername(username string) (*User, bool, error) {
+	users, err := u.Search(username)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, user := range users {
+		if strings.EqualFold(user.UserName, username) {
+			return &user, true, nil
+		}
+	}
+	log.Printf("[DEBUG] no user with username '%s'", username)
+	return nil, false, nil
+}
+
+// Create creates the given user. The Password member is required.
+func (u Users) Create(user User) (*User, error) {
+	return u.request("POST", "/", user)
+}
+
+// Update updates the given user. The server replaces the whole user, including
+// the settings that User does not hold, so the user is read first and those
+// settings are sent back as they were.
+func (u Users) Update(user User) (*User, error) {
+	path := strconv.Itoa(user.ID)
+	data, err := u.server.accessResource("GET", userResource, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	current := make(map[string]json.RawMessage)
+	if err = json.Unmarshal(data, &current); err != nil {
+		log.Printf("[ERROR] error parsing response from /%s/%s: %q", userResource, path, data)
+		return nil, err
+	}
+
+	// the server matches names regardless of case, so replace the settings
+	// User holds whatever their case in the response
+	changes, err := json.Marshal(user)
+	if err != nil {
+		return nil, err
+	}
+	var changed map[string]json.RawMessage
+	if err = json.Unmarshal(changes, &changed); err != nil {
+		return nil, err
+	}
+	for name := range current {
+		if _, found := changed[name]; !found {
+			for changedName := range changed {
+				if strings.EqualFold(name, changedName) {
+					delete(current, name)
+				}
+			}
+		}
+	}
+	for name, value := range changed {
+		current[name] = value
+	}
+	return u.request("PUT", path, current)
+}
+
+// userPatch is the request body for a PATCH of a user
+type userPatch struct {
+	Data struct {
+		Enabled *dirtyValue `json:",omitempty"`
+	}
+}
+
+// Disable disables the user with the given id, so that it can no longer log in.
+// Only the enabled setting of the user is sent.
+func (u Users) Disable(id int) error {
+	var input userPatch
+	input.Data.Enabled = &dirtyValue{Dirty: true, Value: false}
+	_, err := u.request("PATCH", strconv.Itoa(id), input)
+	return err
+}
+
+// ResetTwoFactor clears the two-factor authentication registration of the
+// user with the given id, so that it is prompted to register again
+func (u Users) ResetTwoFactor(id int) error {
+	_, err := u.server.accessResource("POST", userResource, fmt.Sprintf("%d/reset-two-factor", id), nil)
+	return err
+}
+
+// Roles gets the roles assigned to the user with the given id
+func (u Users) Roles(id int) ([]Role, error) {
+	roles := make([]Role, 0)
+
+	err := u.server.listAll(userResource, fmt.Sprintf("%d/roles", id), "", func(data json.RawMessage) error {
+		var page []Role
+		if err := json.Unmarshal(data, &page); err != nil {
+			return err
+		}
+		roles = append(roles, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// AssignRoles assigns the roles with the given ids to the user with the given id
+func (u Users) AssignRoles(id int, roleIDs ...int) error {
+	input := struct{ RoleIDs []int }{RoleIDs: roleIDs}
+	_, err := u.server.accessResource("POST", userResource, fmt.Sprintf("%d/roles", id), input)
+	return err
+}
+
+// UnassignRoles removes the roles with the given ids from the user with the
+// given id
+func (u Users) UnassignRoles(id int, roleIDs ...int) error {
+	input := struct{ RoleIDs []int }{RoleIDs: roleIDs}
+	_, err := u.server.accessResource("DELETE", userResource, fmt.Sprintf("%d/roles", id), input)
+	return err
+}
+
+func (u Users) request(method, path string, input interface{}) (*User, error) {
+	user := new(User)
+
+	if data, err := u.server.accessResource(method, userResource, path, input); err == nil {
+		if err = json.Unmarshal(data, user); err != nil {
+			log.Printf("[ERROR] error parsing response from /%s/%s: %q", userResource, path, data)
+			return nil, err
+		}
+	} else {
+		return nil, err
+	}
+	return user, nil
+}
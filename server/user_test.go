@@ -0,0 +1,106 @@
+package server_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DelineaXPM/tss-sdk-go/v3/server"
+)
+
+// TestUsers tests user administration against the fake
+func TestUsers(t *testing.T) {
+	fake, _, tss := newFakeServer(t)
+	defer fake.Close()
+	auditor := fake.AddRole("Auditor")
+	users := tss.Users()
+
+	created, err := users.Create(server.User{UserName: "jdoe", DisplayName: "Jane Doe", Password: "Passw0rd!",
+		Enabled: true, TwoFactor: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.ID == 0 || created.Password != "" {
+		t.Errorf("expected the created user without its password, found %+v", created)
+	}
+	if _, err = users.Create(server.User{UserName: "JDoe", Password: "Passw0rd!"}); err == nil {
+		t.Error("expected an error creating a user with a username in use")
+	}
+
+	if err = users.ResetTwoFactor(created.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err = users.Disable(created.ID); err != nil {
+		t.Fatal(err)
+	}
+	user, found, err := users.ByUsername("JDOE")
+	if err != nil || !found {
+		t.Fatalf("expected to find the disabled user, found %v (%v)", found, err)
+	}
+	if user.Enabled || user.TwoFactor || user.DisplayName != "Jane Doe" {
+		t.Errorf("expected a disabled user without two-factor authentication, found %+v", user)
+	}
+	if _, found, err = users.ByUsername("jdo"); found || err != nil {
+		t.Errorf("expected a username lookup to match exactly, found %v (%v)", found, err)
+	}
+
+	if err = users.AssignRoles(created.ID, auditor.ID); err != nil {
+		t.Fatal(err)
+	}
+	roles, err := users.Roles(created.ID)
+	if err != nil || len(roles) != 1 || roles[0].Name != "Auditor" {
+		t.Errorf("expected the assigned role, found %+v (%v)", roles, err)
+	}
+	if err = users.UnassignRoles(created.ID, auditor.ID); err != nil {
+		t.Fatal(err)
+	}
+	if roles, err = users.Roles(created.ID); err != nil || len(roles) != 0 {
+		t.Errorf("expected no roles, found %+v (%v)", roles, err)
+	}
+	if err = users.AssignRoles(created.ID, 1000); err == nil {
+		t.Error("expected an error assigning a role that does not exist")
+	}
+	if _, err = users.Get(1000); err == nil {
+		t.Error("expected an error getting a user that does not exist")
+	}
+}
+
+// TestUsersKeepSettings tests that disabling and updating a user keep the
+// settings that User does not hold
+func TestUsersKeepSettings(t *testing.T) {
+	fake, _, tss := newFakeServer(t)
+	defer fake.Close()
+	account := fake.AddApplicationAccount("deploy")
+	users := tss.Users()
+
+	if err := users.Disable(account.ID); err != nil {
+		t.Fatal(err)
+	}
+	var userRequests []string
+	for _, request := range fake.Requests() {
+		if strings.Contains(request, "/users/") {
+			userRequests = append(userRequests, request)
+		}
+	}
+	if len(userRequests) != 1 || !strings.HasPrefix(userRequests[0], "PATCH ") {
+		t.Errorf("expected a single PATCH to disable the user, found %v", userRequests)
+	}
+	user, err := users.Get(account.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Enabled || !fake.IsApplicationAccount(account.ID) {
+		t.Errorf("expected a disabled application account, found %+v", user)
+	}
+
+	user.DisplayName, user.Enabled = "Deployment", true
+	updated, err := users.Update(*user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.DisplayName != "Deployment" || !updated.Enabled || !fake.IsApplicationAccount(account.ID) {
+		t.Errorf("expected an enabled application account named Deployment, found %+v", updated)
+	}
+	if _, err = users.Update(server.User{ID: 1000, UserName: "nobody"}); err == nil {
+		t.Error("expected an error updating a user that does not exist")
+	}
+}
@@ -0,0 +1,283 @@
+package tsstest
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/DelineaXPM/tss-sdk-go/v3/server"
+)
+
+// AddRole adds a role, which users can then be assigned, and returns it
+func (f *Server) AddRole(name string) server.Role {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	role := server.Role{ID: f.newID(), Name: name, Enabled: true}
+	f.roles[role.ID] = &role
+	return role
+}
+
+// userRecord is a user as Secret Server represents it, with a setting that
+// server.User does not hold
+type userRecord struct {
+	server.User
+	IsApplicationAccount bool
+}
+
+// AddApplicationAccount adds an enabled application account, which is a user
+// that cannot log in interactively, and returns it
+func (f *Server) AddApplicationAccount(username string) server.User {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	user := &userRecord{User: server.User{ID: f.newID(), UserName: username, Enabled: true}, IsApplicationAccount: true}
+	f.users[user.ID] = user
+	f.userRoles[user.ID] = make(map[int]bool)
+	return user.User
+}
+
+// IsApplicationAccount returns whether the user with the given id is an
+// application account
+func (f *Server) IsApplicationAccount(id int) bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	user, found := f.users[id]
+	return found && user.IsApplicationAccount
+}
+
+// sortedIDs returns the keys of a set of ids in order
+func sortedIDs(set map[int]bool) []int {
+	ids := make([]int, 0, len(set))
+	for id := range set {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// adminID parses the id of a user or group from the path
+func adminID(parts []string, kind string) (int, error) {
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, errorf(http.StatusBadRequest, "'%s' is not a %s id", parts[0], kind)
+	}
+	return id, nil
+}
+
+func (f *Server) routeUsers(r *http.Request, parts []string) (interface{}, error) {
+	query := r.URL.Query()
+	switch {
+	case len(parts) == 0 && r.Method == "GET":
+		text := strings.ToLower(query.Get("filter.searchText"))
+		includeInactive := query.Get("filter.includeInactive") == "true"
+		users := make([]userRecord, 0)
+		for _, id := range f.userIDs() {
+			user := f.users[id]
+			if (user.Enabled || includeInactive) && (strings.Contains(strings.ToLower(user.UserName), text) ||
+				strings.Contains(strings.ToLower(user.DisplayName), text)) {
+				users = append(users, *user)
+			}
+		}
+		skip, end, response := pageOf(query, len(users))
+		response["Records"] = users[skip:end]
+		return response, nil
+	case len(parts) == 0 && r.Method == "POST":
+		var user userRecord
+		if err := decode(r, &user); err != nil {
+			return nil, err
+		}
+		if user.UserName == "" || user.Password == "" {
+			return nil, errorf(http.StatusBadRequest, "UserName and Password are required")
+		}
+		for _, existing := range f.users {
+			if strings.EqualFold(existing.UserName, user.UserName) {
+				return nil, errorf(http.StatusBadRequest, "the username '%s' is already in use", user.UserName)
+			}
+		}
+		user.ID, user.Password = f.newID(), ""
+		f.users[user.ID] = &user
+		f.userRoles[user.ID] = make(map[int]bool)
+		return user, nil
+	}
+
+	id, err := adminID(parts, "user")
+	if err != nil {
+		return nil, err
+	}
+	user, found := f.users[id]
+	if !found {
+		return nil, errorf(http.StatusNotFound, "there is no user with id %d", id)
+	}
+
+	switch {
+	case len(parts) == 1 && r.Method == "GET":
+		return user, nil
+	case len(parts) == 1 && r.Method == "PUT":
+		var update userRecord
+		if err := decode(r, &update); err != nil {
+			return nil, err
+		}
+		if update.UserName == "" {
+			return nil, errorf(http.StatusBadRequest, "UserName is required")
+		}
+		update.ID, update.Password = id, ""
+		*user = update
+		return user, nil
+	case len(parts) == 1 && r.Method == "PATCH":
+		var input struct {
+			Data struct {
+				Enabled *struct {
+					Dirty, Value bool
+				}
+			}
+		}
+		if err := decode(r, &input); err != nil {
+			return nil, err
+		}
+		if input.Data.Enabled != nil && input.Data.Enabled.Dirty {
+			user.Enabled = input.Data.Enabled.Value
+		}
+		return user, nil
+	case len(parts) == 2 && parts[1] == "reset-two-factor" && r.Method == "POST":
+		user.TwoFactor = false
+		return map[string]interface{}{}, nil
+	case len(parts) == 2 && parts[1] == "roles" && r.Method == "GET":
+		roles := make([]server.Role, 0)
+		for _, roleID := range sortedIDs(f.userRoles[id]) {
+			roles = append(roles, *f.roles[roleID])
+		}
+		skip, end, response := pageOf(query, len(roles))
+		response["Records"] = roles[skip:end]
+		return response, nil
+	case len(parts) == 2 && parts[1] == "roles" && (r.Method == "POST" || r.Method == "DELETE"):
+		var input struct{ RoleIDs []int }
+		if err := decode(r, &input); err != nil {
+			return nil, err
+		}
+		for _, roleID := range input.RoleIDs {
+			if _, found := f.roles[roleID]; !found {
+				return nil, errorf(http.StatusBadRequest, "there is no role with id %d", roleID)
+			}
+		}
+		for _, roleID := range input.RoleIDs {
+			if r.Method == "POST" {
+				f.userRoles[id][roleID] = true
+			} else {
+				delete(f.userRoles[id], roleID)
+			}
+		}
+		return map[string]interface{}{}, nil
+	}
+	return nil, errorf(http.StatusNotFound, "no such endpoint: %s %s", r.Method, r.URL.Path)
+}
+
+func (f *Server) userIDs() []int {
+	set := make(map[int]bool, len(f.users))
+	for id := range f.users {
+		set[id] = true
+	}
+	return sortedIDs(set)
+}
+
+func (f *Server) routeGroups(r *http.Request, parts []string) (interface{}, error) {
+	query := r.URL.Query()
+	switch {
+	case len(parts) == 0 && r.Method == "GET":
+		text := strings.ToLower(query.Get("filter.searchText"))
+		set := make(map[int]bool, len(f.groups))
+		for id, group := range f.groups {
+			if strings.Contains(strings.ToLower(group.Name), text) {
+				set[id] = true
+			}
+		}
+		groups := make([]server.Group, 0, len(set))
+		for _, id := range sortedIDs(set) {
+			groups = append(groups, *f.groups[id])
+		}
+		skip, end, response := pageOf(query, len(groups))
+		response["Records"] = groups[skip:end]
+		return response, nil
+	case len(parts) == 0 && r.Method == "POST":
+		var group server.Group
+		if err := decode(r, &group); err != nil {
+			return nil, err
+		}
+		if group.Name == "" {
+			return nil, errorf(http.StatusBadRequest, "Name is required")
+		}
+		for _, existing := range f.groups {
+			if strings.EqualFold(existing.Name, group.Name) {
+				return nil, errorf(http.StatusBadRequest, "the group name '%s' is already in use", group.Name)
+			}
+		}
+		group.ID = f.newID()
+		f.groups[group.ID] = &group
+		f.groupMembers[group.ID] = make(map[int]bool)
+		return group, nil
+	}
+
+	id, err := adminID(parts, "group")
+	if err != nil {
+		return nil, err
+	}
+	group, found := f.groups[id]
+	if !found {
+		return nil, errorf(http.StatusNotFound, "there is no group with id %d", id)
+	}
+
+	switch {
+	case len(parts) == 1 && r.Method == "GET":
+		return group, nil
+	case len(parts) == 2 && parts[1] == "users" && r.Method == "GET":
+		members := make([]server.GroupMember, 0)
+		for _, userID := range sortedIDs(f.groupMembers[id]) {
+			user := f.users[userID]
+			members = append(members, server.GroupMember{GroupID: id, UserID: userID,
+				UserName: user.UserName, DisplayName: user.DisplayName})
+		}
+		skip, end, response := pageOf(query, len(members))
+		response["Records"] = members[skip:end]
+		return response, nil
+	case len(parts) == 2 && parts[1] == "users" && r.Method == "POST":
+		var input struct{ UserID int }
+		if err := decode(r, &input); err != nil {
+			return nil, err
+		}
+		if _, found := f.users[input.UserID]; !found {
+			return nil, errorf(http.StatusBadRequest, "there is no user with id %d", input.UserID)
+		}
+		f.groupMembers[id][input.UserID] = true
+		return map[string]interface{}{}, nil
+	case len(parts) == 3 && parts[1] == "users" && r.Method == "DELETE":
+		userID, err := adminID(parts[2:], "user")
+		if err != nil {
+			return nil, err
+		}
+		if !f.groupMembers[id][userID] {
+			return nil, errorf(http.StatusNotFound, "the user with id %d is not a member of the group", userID)
+		}
+		delete(f.groupMembers[id], userID)
+		return map[string]interface{}{}, nil
+	}
+	return nil, errorf(http.StatusNotFound, "no such endpoint: %s %s", r.Method, r.URL.Path)
+}
+
+func (f *Server) routeRoles(r *http.Request, parts []string) (interface{}, error) {
+	if len(parts) != 0 || r.Method != "GET" {
+		return nil, errorf(http.StatusNotFound, "no such endpoint: %s %s", r.Method, r.URL.Path)
+	}
+	set := make(map[int]bool, len(f.roles))
+	for id := range f.roles {
+		set[id] = true
+	}
+	roles := make([]server.Role, 0, len(set))
+	for _, id := range sortedIDs(set) {
+		roles = append(roles, *f.roles[id])
+	}
+	skip, end, response := pageOf(r.URL.Query(), len(roles))
+	response["Records"] = roles[skip:end]
+	return response, nil
+}
@@ -0,0 +1,76 @@
+package tsstest
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/DelineaXPM/tss-sdk-go/v3/server"
+)
+
+// secretAudit is an entry in the audit trail of a secret
+type secretAudit struct {
+	SecretAuditID, SecretID                                  int
+	Action, ByUserDisplayName, IPAddress, Notes, MachineName string
+	DateRecorded                                             string
+}
+
+// auditAction returns the action recorded in the audit trail of a secret for
+// a request with the given method and path beneath the secret, or "" if the
+// request is not audited
+func auditAction(method string, parts []string) string {
+	path := ""
+	if len(parts) > 0 {
+		path = parts[0]
+	}
+	switch {
+	case path == "" && method == "GET", path == "fields" && method == "GET":
+		return "VIEW"
+	case path == "" && method == "PUT", path == "general", path == "expiration", path == "fields":
+		return "EDIT"
+	case path == "" && method == "DELETE":
+		return "DELETE"
+	case path == "undelete":
+		return "UNDELETE"
+	case path == "change-password":
+		return "CHANGE PASSWORD"
+	case path == "heartbeat":
+		return "HEARTBEAT"
+	case path == "password-history":
+		return "VIEW PASSWORD HISTORY"
+	}
+	return ""
+}
+
+// audit records the action in the audit trail of the secret with the given id
+func (f *Server) audit(r *http.Request, id int, action string) {
+	if action == "" {
+		return
+	}
+	address, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		address = r.RemoteAddr
+	}
+	f.trails[id] = append(f.trails[id], secretAudit{
+		SecretAuditID:     f.newID(),
+		SecretID:          id,
+		Action:            action,
+		ByUserDisplayName: Username,
+		IPAddress:         address,
+		MachineName:       "tsstest",
+		DateRecorded:      serverTime(time.Now()),
+	})
+}
+
+// audits returns the requested page of the audit trail of the secret, most
+// recent first
+func (f *Server) audits(r *http.Request, secret *server.Secret) (interface{}, error) {
+	trail := f.trails[secret.ID]
+	records := make([]secretAudit, len(trail))
+	for i, audit := range trail {
+		records[len(trail)-1-i] = audit
+	}
+	skip, end, response := pageOf(r.URL.Query(), len(records))
+	response["Records"] = records[skip:end]
+	return response, nil
+}
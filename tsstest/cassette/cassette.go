@@ -0,0 +1,237 @@
+// Package cassette records HTTP interactions with Secret Server to cassette
+// files and replays them, so that integration tests can run without a live
+// server.
+//
+// A Recorder is an http.RoundTripper that passes requests on to the server
+// and records them, sanitized by a Sanitizer: access tokens and credentials
+// are redacted, known secret values are replaced with placeholders, and
+// field values that the client did not itself send are masked. A Player is
+// an http.RoundTripper that replays the recorded responses in order; Close
+// reports the interactions that were not replayed.
+//
+// Set either as the Transport of a server.Configuration.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Cassette is a recorded sequence of interactions
+type Cassette struct {
+	// Environment holds the (sanitized) environment variables of the test
+	// that was recorded, for the test to use when it is replayed
+	Environment  map[string]string `json:",omitempty"`
+	Interactions []Interaction
+}
+
+// Interaction is a request and the response to it
+type Interaction struct {
+	Request  Request
+	Response Response
+}
+
+// Request is a recorded request
+type Request struct {
+	Method, URL string
+	Body        string `json:",omitempty"`
+}
+
+// Response is a recorded response
+type Response struct {
+	StatusCode  int
+	ContentType string `json:",omitempty"`
+	Body        string
+}
+
+// Load reads the cassette at path
+func Load(path string) (*Cassette, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cassette := new(Cassette)
+	if err = json.Unmarshal(data, cassette); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return cassette, nil
+}
+
+// Save writes the cassette to path, creating its directory if needed
+func (c *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Recorder is an http.RoundTripper that records the interactions it passes
+// on to Transport
+type Recorder struct {
+	// Transport sends the requests; http.DefaultTransport if nil
+	Transport http.RoundTripper
+	// Sanitizer scrubs the interactions before they are recorded
+	Sanitizer *Sanitizer
+	// Path, if set, is where the cassette is saved after every interaction,
+	// so that nothing is lost if the test stops early
+	Path string
+
+	mutex    sync.Mutex
+	cassette Cassette
+}
+
+// NewRecorder returns a Recorder that saves the cassette to path
+func NewRecorder(path string, sanitizer *Sanitizer) *Recorder {
+	return &Recorder{Sanitizer: sanitizer, Path: path}
+}
+
+// Cassette returns the interactions recorded so far
+func (r *Recorder) Cassette() *Cassette {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	copied := r.cassette
+	copied.Interactions = append([]Interaction(nil), r.cassette.Interactions...)
+	return &copied
+}
+
+// SetEnvironment sets the environment saved with the cassette
+func (r *Recorder) SetEnvironment(environment map[string]string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.cassette.Environment = environment
+}
+
+// RoundTrip sends the request and records the sanitized interaction. The
+// response returned to the caller is not sanitized.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var requestBody []byte
+	if req.Body != nil {
+		var err error
+		if requestBody, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(requestBody))
+	}
+
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	res, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	responseBody, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(responseBody))
+
+	sanitizer := r.Sanitizer
+	if sanitizer == nil {
+		sanitizer = NewSanitizer()
+	}
+	interaction := Interaction{
+		Request: Request{
+			Method: req.Method,
+			URL:    sanitizer.text(req.URL.String()),
+			Body:   sanitizer.request(req.Header.Get("Content-Type"), requestBody),
+		},
+		Response: Response{
+			StatusCode:  res.StatusCode,
+			ContentType: res.Header.Get("Content-Type"),
+			Body:        sanitizer.response(responseBody),
+		},
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	if r.Path != "" {
+		if err := r.cassette.Save(r.Path); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// Player is an http.RoundTripper that replays the interactions of a cassette
+// in the order in which they were recorded. Each request must have the method
+// and URL of the next interaction; request bodies are not compared.
+type Player struct {
+	mutex    sync.Mutex
+	cassette *Cassette
+	next     int
+}
+
+// NewPlayer returns a Player for the cassette
+func NewPlayer(cassette *Cassette) *Player {
+	return &Player{cassette: cassette}
+}
+
+// RoundTrip returns the recorded response to the request
+func (p *Player) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.next >= len(p.cassette.Interactions) {
+		return nil, fmt.Errorf("cassette: no recorded interaction for %s %s", req.Method, req.URL)
+	}
+	interaction := p.cassette.Interactions[p.next]
+	if interaction.Request.Method != req.Method || interaction.Request.URL != req.URL.String() {
+		return nil, fmt.Errorf("cassette: expected %s %s as interaction %d, but found %s %s",
+			interaction.Request.Method, interaction.Request.URL, p.next, req.Method, req.URL)
+	}
+	p.next++
+
+	header := make(http.Header)
+	if interaction.Response.ContentType != "" {
+		header.Set("Content-Type", interaction.Response.ContentType)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+		StatusCode:    interaction.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(strings.NewReader(interaction.Response.Body)),
+		ContentLength: int64(len(interaction.Response.Body)),
+		Request:       req,
+	}, nil
+}
+
+// Remaining returns the number of interactions that have not been replayed
+func (p *Player) Remaining() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return len(p.cassette.Interactions) - p.next
+}
+
+// Close returns an error if any of the interactions have not been replayed,
+// since a test that makes fewer requests than were recorded has changed
+func (p *Player) Close() error {
+	if remaining := p.Remaining(); remaining != 0 {
+		next := p.cassette.Interactions[len(p.cassette.Interactions)-remaining].Request
+		return fmt.Errorf("cassette: %d recorded interactions were not replayed, starting with %s %s",
+			remaining, next.Method, next.URL)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package cassette_test
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DelineaXPM/tss-sdk-go/v3/server"
+	"github.com/DelineaXPM/tss-sdk-go/v3/tsstest"
+	"github.com/DelineaXPM/tss-sdk-go/v3/tsstest/cassette"
+)
+
+const (
+	replayURL      = "https://tss.example.com"
+	replayUsername = "redacted-username"
+	replayPassword = "redacted-password"
+)
+
+type results struct {
+	generated, password, username string
+	id                            int
+}
+
+// exercise generates a password, creates a secret with it, reads the secret
+// back and deletes it
+func exercise(t *testing.T, config server.Configuration, template *server.SecretTemplate) results {
+	tss, err := server.New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	generated, err := tss.GeneratePassword("password", template)
+	if err != nil {
+		t.Fatal("generating a password:", err)
+	}
+	created, err := tss.CreateSecret(server.Secret{
+		Name: "DB", SiteID: 1, FolderID: -1, SecretTemplateID: template.ID,
+		Fields: []server.SecretField{{Slug: "username", ItemValue: "app"}, {Slug: "password", ItemValue: "correct horse"}},
+	})
+	if err != nil {
+		t.Fatal("creating the secret:", err)
+	}
+	read, err := tss.Secret(created.ID)
+	if err != nil {
+		t.Fatal("reading the secret:", err)
+	}
+	if err = tss.DeleteSecret(created.ID); err != nil {
+		t.Fatal("deleting the secret:", err)
+	}
+	password, _ := read.Field("password")
+	username, _ := read.Field("username")
+	return results{generated: generated, password: password, username: username, id: read.ID}
+}
+
+// TestRecordAndReplay records a session with a fake server, checks that the
+// cassette is sanitized, then replays it with the fake shut down
+func TestRecordAndReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cassette")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "session.json")
+
+	fake := tsstest.NewServer()
+	template := fake.AddTemplate(tsstest.PasswordTemplate())
+	sanitizer := cassette.NewSanitizer()
+	sanitizer.Replace(fake.URL, replayURL)
+	sanitizer.Replace(tsstest.Username, replayUsername)
+	sanitizer.Replace(tsstest.Password, replayPassword)
+	recorder := cassette.NewRecorder(path, sanitizer)
+	recorder.SetEnvironment(map[string]string{"TSS_SERVER_URL": replayURL})
+
+	config := fake.Configuration()
+	config.Transport = recorder
+	recorded := exercise(t, config, &template)
+	fake.Close()
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, leaked := range []string{fake.URL, tsstest.Password, "tsstest-", recorded.generated} {
+		if strings.Contains(string(data), leaked) {
+			t.Errorf("expected the cassette not to contain %q", leaked)
+		}
+	}
+	if !strings.Contains(string(data), "correct horse") {
+		t.Error("expected the cassette to keep the password the client sent")
+	}
+
+	loaded, err := cassette.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Environment["TSS_SERVER_URL"] != replayURL {
+		t.Errorf("expected the environment to be saved, found %v", loaded.Environment)
+	}
+	player := cassette.NewPlayer(loaded)
+	os.Unsetenv("SS_AT_" + url.QueryEscape(replayURL))
+	replayed := exercise(t, server.Configuration{
+		ServerURL:   replayURL,
+		Credentials: server.UserCredential{Username: replayUsername, Password: replayPassword},
+		Transport:   player,
+	}, &template)
+
+	if replayed.generated != strings.Repeat("x", len(recorded.generated)) {
+		t.Errorf("expected the generated password to be masked, found %q", replayed.generated)
+	}
+	replayed.generated = recorded.generated
+	if replayed != recorded {
+		t.Errorf("expected the replay to give %+v, found %+v", recorded, replayed)
+	}
+	if err := player.Close(); err != nil {
+		t.Errorf("expected every interaction to be replayed, found %v", err)
+	}
+}
+
+// TestPlayerMismatch tests that the player rejects requests out of order
+func TestPlayerMismatch(t *testing.T) {
+	player := cassette.NewPlayer(&cassette.Cassette{Interactions: []cassette.Interaction{{
+		Request:  cassette.Request{Method: "GET", URL: replayURL + "/api/v1/healthcheck"},
+		Response: cassette.Response{StatusCode: 200, Body: "{}"},
+	}}})
+	tss, err := server.New(server.Configuration{ServerURL: replayURL,
+		Credentials: server.UserCredential{Token: "token"}, Transport: player})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tss.Secret(1); err == nil || !strings.Contains(err.Error(), "expected GET "+replayURL+"/api/v1/healthcheck") {
+		t.Errorf("expected a mismatch error, found %v", err)
+	}
+	if player.Remaining() != 1 {
+		t.Error("expected the mismatched interaction not to be consumed")
+	}
+	if err = player.Close(); err == nil || !strings.Contains(err.Error(), "1 recorded interactions were not replayed") {
+		t.Errorf("expected an error for the interaction that was not replayed, found %v", err)
+	}
+}
@@ -0,0 +1,189 @@
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces access tokens and the bodies of credential requests
+const Redacted = "REDACTED"
+
+// tokenKeys are the JSON keys whose values are redacted from responses
+var tokenKeys = map[string]bool{"access_token": true, "refresh_token": true, "id_token": true}
+
+// valueKeys are the JSON keys that hold field values in requests and
+// responses
+var valueKeys = map[string]bool{"itemvalue": true, "value": true}
+
+// Sanitizer scrubs recorded interactions:
+//
+//   - known secrets, such as credentials and the base URL of the server, are
+//     replaced with placeholders wherever they appear;
+//   - access tokens in responses, and the bodies of form requests, which
+//     carry credentials, are redacted;
+//   - field values in responses, and responses that are bare strings, such as
+//     generated passwords and file contents, are masked with x's of the same
+//     length, unless the client sent the value itself earlier in the
+//     recording, so that tests can still compare what they wrote with what
+//     they read back.
+type Sanitizer struct {
+	mutex        sync.Mutex
+	replacements []replacement
+	sent         map[string]bool
+}
+
+type replacement struct {
+	value, placeholder string
+}
+
+// NewSanitizer returns a Sanitizer with no known secrets
+func NewSanitizer() *Sanitizer {
+	return &Sanitizer{sent: make(map[string]bool)}
+}
+
+// Replace registers a secret to be replaced with the placeholder, including
+// where it appears escaped in JSON or in URLs. Empty values are ignored.
+func (s *Sanitizer) Replace(value, placeholder string) {
+	if value == "" || value == placeholder {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	quoted, _ := json.Marshal(value)
+	for _, variant := range []string{value, string(quoted[1 : len(quoted)-1]), url.QueryEscape(value), url.PathEscape(value)} {
+		s.replacements = append(s.replacements, replacement{variant, placeholder})
+	}
+	// replace longer values first, so that a secret containing another is
+	// replaced whole
+	sort.SliceStable(s.replacements, func(i, j int) bool {
+		return len(s.replacements[i].value) > len(s.replacements[j].value)
+	})
+}
+
+// text replaces the known secrets in the text
+func (s *Sanitizer) text(text string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, r := range s.replacements {
+		text = strings.Replace(text, r.value, r.placeholder, -1)
+	}
+	return text
+}
+
+// request sanitizes a request body and remembers the field values in it
+func (s *Sanitizer) request(contentType string, body []byte) string {
+	switch {
+	case len(body) == 0:
+		return ""
+	case strings.HasPrefix(contentType, "application/x-www-form-urlencoded"),
+		strings.HasPrefix(contentType, "multipart/form-data"):
+		return Redacted
+	}
+	text := s.text(string(body))
+
+	var document interface{}
+	if json.Unmarshal([]byte(text), &document) == nil {
+		s.mutex.Lock()
+		s.collectSent(document)
+		s.mutex.Unlock()
+	}
+	return text
+}
+
+// collectSent remembers the field values in the document
+func (s *Sanitizer) collectSent(document interface{}) {
+	switch value := document.(type) {
+	case map[string]interface{}:
+		for key, child := range value {
+			if text, ok := child.(string); ok && valueKeys[strings.ToLower(key)] {
+				s.sent[text] = true
+			} else {
+				s.collectSent(child)
+			}
+		}
+	case []interface{}:
+		for _, child := range value {
+			s.collectSent(child)
+		}
+	}
+}
+
+// response sanitizes a response body
+func (s *Sanitizer) response(body []byte) string {
+	text := s.text(string(body))
+
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil || decoder.More() {
+		// not JSON: the contents of a file
+		return s.mask(text)
+	}
+
+	s.mutex.Lock()
+	document = s.scrub(document)
+	s.mutex.Unlock()
+
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(document)
+	return strings.TrimSuffix(buffer.String(), "\n")
+}
+
+// scrub redacts the tokens and masks the field values in the document
+func (s *Sanitizer) scrub(document interface{}) interface{} {
+	switch value := document.(type) {
+	case string:
+		return s.maskLocked(value)
+	case map[string]interface{}:
+		for key, child := range value {
+			text, isString := child.(string)
+			switch {
+			case isString && tokenKeys[strings.ToLower(key)]:
+				value[key] = Redacted
+			case isString && valueKeys[strings.ToLower(key)]:
+				value[key] = s.maskLocked(text)
+			case !isString:
+				value[key] = s.scrubNested(child)
+			}
+		}
+	case []interface{}:
+		for i, child := range value {
+			value[i] = s.scrubNested(child)
+		}
+	}
+	return document
+}
+
+// scrubNested scrubs a nested value, leaving strings other than field values
+func (s *Sanitizer) scrubNested(document interface{}) interface{} {
+	if _, isString := document.(string); isString {
+		return document
+	}
+	return s.scrub(document)
+}
+
+func (s *Sanitizer) mask(value string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.maskLocked(value)
+}
+
+// maskLocked masks the value unless the client sent it
+func (s *Sanitizer) maskLocked(value string) string {
+	if value == "" || s.sent[value] {
+		return value
+	}
+	for _, r := range s.replacements {
+		if strings.Contains(value, r.placeholder) {
+			return value
+		}
+	}
+	return strings.Repeat("x", len(value))
+}
@@ -0,0 +1,38 @@
+package tsstest
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// defaultTake is the number of records Secret Server returns per page when
+// the request does not say
+const defaultTake = 10
+
+// pageOf returns the page of a list of total records selected by the skip and
+// take, or paging.skip and paging.take, query parameters, as the bounds of
+// the records on it and the paging members of the response
+func pageOf(query url.Values, total int) (int, int, map[string]interface{}) {
+	number := func(name string, value int) int {
+		for _, key := range []string{name, "paging." + name} {
+			if n, err := strconv.Atoi(query.Get(key)); err == nil && n >= 0 {
+				value = n
+			}
+		}
+		return value
+	}
+	skip, take := number("skip", 0), number("take", defaultTake)
+	if skip > total {
+		skip = total
+	}
+	end := skip + take
+	if end > total {
+		end = total
+	}
+	return skip, end, map[string]interface{}{
+		"Skip":    skip,
+		"Take":    take,
+		"Total":   total,
+		"HasNext": end < total,
+	}
+}
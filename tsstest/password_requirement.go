@@ -0,0 +1,93 @@
+package tsstest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/DelineaXPM/tss-sdk-go/v3/server"
+)
+
+// defaultPasswordRequirement is the password requirement of the password
+// fields that SetPasswordRequirement was not called for
+var defaultPasswordRequirement = server.PasswordRequirement{
+	ID:          1,
+	Name:        "Default Requirement",
+	Description: "At least 12 characters",
+	MinLength:   12,
+	MaxLength:   50,
+}
+
+// passwordRequirementRecord is a password requirement as Secret Server
+// represents it
+type passwordRequirementRecord struct {
+	ID                                   int
+	Name, Description                    string
+	MinPasswordLength, MaxPasswordLength int
+	AllowedCharacterSet                  characterSet
+	CharacterSetRules                    []characterSetRule
+}
+
+type characterSet struct {
+	Name, Characters string
+}
+
+type characterSetRule struct {
+	CharacterSet characterSet
+	MinRequired  int
+}
+
+// SetPasswordRequirement sets the password requirement of the password field
+// with the given id. Password fields without one require at least 12
+// characters.
+func (f *Server) SetPasswordRequirement(fieldID int, requirement server.PasswordRequirement) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.requirements[fieldID] = requirement
+}
+
+// passwordRequirement returns the password requirement of the password field
+// with the given id
+func (f *Server) passwordRequirement(field string) (interface{}, error) {
+	fieldID, err := strconv.Atoi(field)
+	if err != nil {
+		return nil, errorf(http.StatusBadRequest, "'%s' is not a field id", field)
+	}
+	if !f.isPasswordField(fieldID) {
+		return nil, errorf(http.StatusBadRequest, "there is no password field with id %d", fieldID)
+	}
+	requirement, found := f.requirements[fieldID]
+	if !found {
+		requirement = defaultPasswordRequirement
+	}
+
+	record := passwordRequirementRecord{
+		ID:                  requirement.ID,
+		Name:                requirement.Name,
+		Description:         requirement.Description,
+		MinPasswordLength:   requirement.MinLength,
+		MaxPasswordLength:   requirement.MaxLength,
+		AllowedCharacterSet: characterSet{Name: "Allowed", Characters: requirement.AllowedCharacters},
+		CharacterSetRules:   make([]characterSetRule, len(requirement.Rules)),
+	}
+	for i, rule := range requirement.Rules {
+		record.CharacterSetRules[i] = characterSetRule{
+			CharacterSet: characterSet{Name: rule.Name, Characters: rule.Characters},
+			MinRequired:  rule.MinRequired,
+		}
+	}
+	return record, nil
+}
+
+// isPasswordField returns whether a template has a password field with the
+// given id
+func (f *Server) isPasswordField(fieldID int) bool {
+	for _, template := range f.templates {
+		for _, field := range template.Fields {
+			if field.SecretTemplateFieldID == fieldID && field.IsPassword {
+				return true
+			}
+		}
+	}
+	return false
+}
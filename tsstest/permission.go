@@ -0,0 +1,172 @@
+package tsstest
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+
+	"github.com/DelineaXPM/tss-sdk-go/v3/server"
+)
+
+var (
+	secretRoles = map[string]bool{server.SecretRoleList: true, server.SecretRoleView: true,
+		server.SecretRoleEdit: true, server.SecretRoleOwner: true}
+	folderRoles = map[string]bool{server.FolderRoleView: true, server.FolderRoleEdit: true,
+		server.FolderRoleAddSecret: true, server.FolderRoleOwner: true}
+)
+
+// checkGrantee checks that a permission is granted to exactly one user or
+// group
+func checkGrantee(userID, groupID int) error {
+	if (userID == 0) == (groupID == 0) {
+		return errorf(http.StatusBadRequest, "exactly one of UserId and GroupId is required")
+	}
+	return nil
+}
+
+// permissionID parses the id of a permission from the path
+func permissionID(parts []string) (int, error) {
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, errorf(http.StatusBadRequest, "'%s' is not a permission id", parts[0])
+	}
+	return id, nil
+}
+
+// filterID returns the value of the given integer query parameter, if any
+func filterID(query url.Values, name string) (int, bool) {
+	id, err := strconv.Atoi(query.Get(name))
+	return id, err == nil
+}
+
+func (f *Server) routeSecretPermissions(r *http.Request, parts []string) (interface{}, error) {
+	if len(parts) == 0 && r.Method == "GET" {
+		secretID, filtered := filterID(r.URL.Query(), "filter.secretId")
+		permissions := make([]server.SecretPermission, 0)
+		for _, permission := range f.secretPermissions {
+			if !filtered || permission.SecretID == secretID {
+				permissions = append(permissions, *permission)
+			}
+		}
+		sort.Slice(permissions, func(i, j int) bool { return permissions[i].ID < permissions[j].ID })
+		skip, end, response := pageOf(r.URL.Query(), len(permissions))
+		response["Records"] = permissions[skip:end]
+		return response, nil
+	}
+
+	var input server.SecretPermission
+	if r.Method == "POST" || r.Method == "PUT" {
+		if err := decode(r, &input); err != nil {
+			return nil, err
+		}
+		if secret, found := f.secrets[input.SecretID]; !found || !secret.Active {
+			return nil, errorf(http.StatusBadRequest, "SecretId is required and must be an active secret")
+		}
+		if err := checkGrantee(input.UserID, input.GroupID); err != nil {
+			return nil, err
+		}
+		if !secretRoles[input.SecretAccessRoleName] {
+			return nil, errorf(http.StatusBadRequest, "'%s' is not a secret access role", input.SecretAccessRoleName)
+		}
+	}
+	if len(parts) == 0 && r.Method == "POST" {
+		input.ID = f.newID()
+		f.secretPermissions[input.ID] = &input
+		return input, nil
+	}
+	if len(parts) != 1 {
+		return nil, errorf(http.StatusNotFound, "no such endpoint: %s %s", r.Method, r.URL.Path)
+	}
+
+	id, err := permissionID(parts)
+	if err != nil {
+		return nil, err
+	}
+	permission, found := f.secretPermissions[id]
+	if !found {
+		return nil, errorf(http.StatusNotFound, "there is no secret permission with id %d", id)
+	}
+	switch r.Method {
+	case "GET":
+		return permission, nil
+	case "PUT":
+		if input.SecretID != permission.SecretID || input.UserID != permission.UserID || input.GroupID != permission.GroupID {
+			return nil, errorf(http.StatusBadRequest, "the secret, user and group of a permission cannot be changed")
+		}
+		input.ID = id
+		*permission = input
+		return permission, nil
+	case "DELETE":
+		delete(f.secretPermissions, id)
+		return map[string]interface{}{"id": id, "objectType": "SecretPermission"}, nil
+	}
+	return nil, errorf(http.StatusMethodNotAllowed, "%s is not supported for secret permissions", r.Method)
+}
+
+func (f *Server) routeFolderPermissions(r *http.Request, parts []string) (interface{}, error) {
+	if len(parts) == 0 && r.Method == "GET" {
+		folderID, filtered := filterID(r.URL.Query(), "filter.folderId")
+		permissions := make([]server.FolderPermission, 0)
+		for _, permission := range f.folderPermissions {
+			if !filtered || permission.FolderID == folderID {
+				permissions = append(permissions, *permission)
+			}
+		}
+		sort.Slice(permissions, func(i, j int) bool { return permissions[i].ID < permissions[j].ID })
+		skip, end, response := pageOf(r.URL.Query(), len(permissions))
+		response["Records"] = permissions[skip:end]
+		return response, nil
+	}
+
+	var input server.FolderPermission
+	if r.Method == "POST" || r.Method == "PUT" {
+		if err := decode(r, &input); err != nil {
+			return nil, err
+		}
+		if _, found := f.folders[input.FolderID]; !found {
+			return nil, errorf(http.StatusBadRequest, "FolderId is required and must be a folder")
+		}
+		if err := checkGrantee(input.UserID, input.GroupID); err != nil {
+			return nil, err
+		}
+		if !folderRoles[input.FolderAccessRoleName] {
+			return nil, errorf(http.StatusBadRequest, "'%s' is not a folder access role", input.FolderAccessRoleName)
+		}
+		if !secretRoles[input.SecretAccessRoleName] {
+			return nil, errorf(http.StatusBadRequest, "'%s' is not a secret access role", input.SecretAccessRoleName)
+		}
+	}
+	if len(parts) == 0 && r.Method == "POST" {
+		input.ID = f.newID()
+		f.folderPermissions[input.ID] = &input
+		return input, nil
+	}
+	if len(parts) != 1 {
+		return nil, errorf(http.StatusNotFound, "no such endpoint: %s %s", r.Method, r.URL.Path)
+	}
+
+	id, err := permissionID(parts)
+	if err != nil {
+		return nil, err
+	}
+	permission, found := f.folderPermissions[id]
+	if !found {
+		return nil, errorf(http.StatusNotFound, "there is no folder permission with id %d", id)
+	}
+	switch r.Method {
+	case "GET":
+		return permission, nil
+	case "PUT":
+		if input.FolderID != permission.FolderID || input.UserID != permission.UserID || input.GroupID != permission.GroupID {
+			return nil, errorf(http.StatusBadRequest, "the folder, user and group of a permission cannot be changed")
+		}
+		input.ID = id
+		*permission = input
+		return permission, nil
+	case "DELETE":
+		delete(f.folderPermissions, id)
+		return map[string]interface{}{"id": id, "objectType": "FolderPermission"}, nil
+	}
+	return nil, errorf(http.StatusMethodNotAllowed, "%s is not supported for folder permissions", r.Method)
+}
@@ -0,0 +1,170 @@
+package tsstest
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/DelineaXPM/tss-sdk-go/v3/server"
+)
+
+// Remote describes how the system that the credentials of a secret belong to
+// responds to the remote password changes and heartbeats of the fake
+type Remote struct {
+	// Polls is the number of summary requests for which a password change or
+	// heartbeat stays in progress before it completes
+	Polls int
+	// Failure, if set, is the reason password changes fail, and makes
+	// heartbeats fail as well
+	Failure string
+}
+
+// remoteState is the remote password change and heartbeat state of a secret
+type remoteState struct {
+	Remote
+	attempted, checked time.Time
+	newPassword        *string
+	changePolls        int
+	heartbeat          string
+	heartbeatPolls     int
+	outOfSync          bool
+	outOfSyncReason    string
+	passwordHistory    []passwordHistoryEntry
+}
+
+// passwordHistoryEntry is a replaced password of a secret
+type passwordHistoryEntry struct {
+	Password, UserDisplayName, Date string
+}
+
+// SetRemote sets how the system of the secret with the given id responds to
+// remote password changes and heartbeats. By default they succeed at once.
+func (f *Server) SetRemote(id int, remote Remote) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.remote(id).Remote = remote
+}
+
+func (f *Server) remote(id int) *remoteState {
+	state, found := f.remotes[id]
+	if !found {
+		state = new(remoteState)
+		f.remotes[id] = state
+	}
+	return state
+}
+
+// SetClockSkew sets how far the clock of the fake is ahead of the clock of
+// the client, which a negative skew puts it behind. It applies to the times
+// of remote password changes, heartbeats and the password history.
+func (f *Server) SetClockSkew(skew time.Duration) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.clockSkew = skew
+}
+
+// now returns the time on the clock of the fake
+func (f *Server) now() time.Time {
+	return time.Now().Add(f.clockSkew)
+}
+
+// serverTime renders a time as Secret Server does
+func serverTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// changePassword starts a remote password change of the secret
+func (f *Server) changePassword(r *http.Request, secret *server.Secret) (interface{}, error) {
+	var input struct{ NewPassword string }
+	if err := decode(r, &input); err != nil {
+		return nil, err
+	}
+	if input.NewPassword == "" {
+		return nil, errorf(http.StatusBadRequest, "a new password is required")
+	}
+	state := f.remote(secret.ID)
+	state.attempted = f.now()
+	state.newPassword = &input.NewPassword
+	state.changePolls = state.Polls
+	return map[string]interface{}{}, nil
+}
+
+// heartbeat starts a heartbeat of the secret
+func (f *Server) heartbeat(secret *server.Secret) (interface{}, error) {
+	state := f.remote(secret.ID)
+	state.checked = f.now()
+	state.heartbeat = server.HeartbeatProcessing
+	state.heartbeatPolls = state.Polls
+	return map[string]interface{}{}, nil
+}
+
+// summary advances the password change and heartbeat in progress, if any,
+// and returns the summary of the secret
+func (f *Server) summary(secret *server.Secret) (interface{}, error) {
+	state := f.remote(secret.ID)
+	if state.newPassword != nil {
+		if state.changePolls > 0 {
+			state.changePolls--
+		} else {
+			f.completePasswordChange(secret, state)
+		}
+	}
+	if state.heartbeat == server.HeartbeatProcessing {
+		if state.heartbeatPolls > 0 {
+			state.heartbeatPolls--
+		} else if state.Failure != "" {
+			state.heartbeat = server.HeartbeatFailed
+		} else {
+			state.heartbeat = server.HeartbeatSuccess
+		}
+	}
+	return map[string]interface{}{
+		"Id":                        secret.ID,
+		"Name":                      secret.Name,
+		"LastHeartBeatStatus":       state.heartbeat,
+		"LastHeartBeatCheck":        serverTime(state.checked),
+		"LastPasswordChangeAttempt": serverTime(state.attempted),
+		"IsOutOfSync":               state.outOfSync,
+		"OutOfSyncReason":           state.outOfSyncReason,
+	}, nil
+}
+
+// completePasswordChange finishes the password change in progress, which
+// either fails and leaves the secret out of sync, or replaces the password
+func (f *Server) completePasswordChange(secret *server.Secret, state *remoteState) {
+	newPassword := *state.newPassword
+	state.newPassword = nil
+	if state.Failure != "" {
+		state.outOfSync, state.outOfSyncReason = true, state.Failure
+		return
+	}
+	state.outOfSync, state.outOfSyncReason = false, ""
+	for i, field := range secret.Fields {
+		if field.IsPassword {
+			f.setPassword(secret, i, newPassword)
+			return
+		}
+	}
+}
+
+// setPassword sets the value of the password field with the given index,
+// keeping the password it replaces in the password history
+func (f *Server) setPassword(secret *server.Secret, index int, password string) {
+	field := &secret.Fields[index]
+	if field.ItemValue == password {
+		return
+	}
+	state := f.remote(secret.ID)
+	entry := passwordHistoryEntry{Password: field.ItemValue, UserDisplayName: Username, Date: serverTime(f.now())}
+	state.passwordHistory = append([]passwordHistoryEntry{entry}, state.passwordHistory...)
+	field.ItemValue = password
+}
+
+// passwordHistory returns the replaced passwords of the secret, most recent
+// first
+func (f *Server) passwordHistory(secret *server.Secret) (interface{}, error) {
+	records := append([]passwordHistoryEntry{}, f.remote(secret.ID).passwordHistory...)
+	return map[string]interface{}{"Records": records, "HasNext": false, "Take": len(records)}, nil
+}
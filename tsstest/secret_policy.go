@@ -0,0 +1,63 @@
+package tsstest
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/DelineaXPM/tss-sdk-go/v3/server"
+)
+
+// secretPolicyRecord is a secret policy as Secret Server represents it
+type secretPolicyRecord struct {
+	SecretPolicyID                            int
+	SecretPolicyName, SecretPolicyDescription string
+	Active                                    bool
+}
+
+// AddSecretPolicy adds an active secret policy, which can then be applied to
+// secrets, and returns it
+func (f *Server) AddSecretPolicy(name, description string) server.SecretPolicy {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	policy := server.SecretPolicy{ID: f.newID(), Name: name, Description: description, Active: true}
+	f.secretPolicies[policy.ID] = &policy
+	return policy
+}
+
+func (f *Server) routeSecretPolicies(r *http.Request, parts []string) (interface{}, error) {
+	record := func(policy *server.SecretPolicy) secretPolicyRecord {
+		return secretPolicyRecord{policy.ID, policy.Name, policy.Description, policy.Active}
+	}
+	switch {
+	case len(parts) == 1 && parts[0] == "search" && r.Method == "GET":
+		query := r.URL.Query()
+		name := strings.ToLower(query.Get("filter.secretPolicyName"))
+		includeInactive := query.Get("filter.includeInactive") == "true"
+		set := make(map[int]bool, len(f.secretPolicies))
+		for id, policy := range f.secretPolicies {
+			if (policy.Active || includeInactive) && strings.Contains(strings.ToLower(policy.Name), name) {
+				set[id] = true
+			}
+		}
+		records := make([]secretPolicyRecord, 0, len(set))
+		for _, id := range sortedIDs(set) {
+			records = append(records, record(f.secretPolicies[id]))
+		}
+		skip, end, response := pageOf(query, len(records))
+		response["Records"] = records[skip:end]
+		return response, nil
+	case len(parts) == 1 && r.Method == "GET":
+		id, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return nil, errorf(http.StatusBadRequest, "'%s' is not a secret policy id", parts[0])
+		}
+		policy, found := f.secretPolicies[id]
+		if !found {
+			return nil, errorf(http.StatusNotFound, "there is no secret policy with id %d", id)
+		}
+		return record(policy), nil
+	}
+	return nil, errorf(http.StatusNotFound, "no such endpoint: %s %s", r.Method, r.URL.Path)
+}
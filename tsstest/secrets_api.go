@@ -0,0 +1,39 @@
+package tsstest
+
+import (
+	"github.com/DelineaXPM/tss-sdk-go/v3/server"
+)
+
+// PassThrough returns a SecretsAPIMock that passes every call to api and
+// records it, so that a test can check the calls that code makes to a real
+// client
+func PassThrough(api server.SecretsAPI) *SecretsAPIMock {
+	return &SecretsAPIMock{
+		SecretFunc:           api.Secret,
+		SecretsFunc:          api.Secrets,
+		SecretByPathFunc:     api.SecretByPath,
+		FolderSecretsFunc:    api.FolderSecrets,
+		CreateSecretFunc:     api.CreateSecret,
+		UpdateSecretFunc:     api.UpdateSecret,
+		UpdateFieldFunc:      api.UpdateField,
+		DeleteSecretFunc:     api.DeleteSecret,
+		SecretTemplateFunc:   api.SecretTemplate,
+		GeneratePasswordFunc: api.GeneratePassword,
+	}
+}
+
+// NewSecretsAPI starts a fake Secret Server seeded with the fixtures, and
+// returns it with a client for it and a PassThrough mock of the client. The
+// caller closes the fake.
+func NewSecretsAPI(fixtures Fixtures) (*Server, *server.Server, *SecretsAPIMock, error) {
+	f, err := NewServerWithFixtures(fixtures)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	tss, err := server.New(f.Configuration())
+	if err != nil {
+		f.Close()
+		return nil, nil, nil, err
+	}
+	return f, tss, PassThrough(tss), nil
+}
@@ -0,0 +1,63 @@
+package tsstest
+
+import (
+	"testing"
+
+	"github.com/DelineaXPM/tss-sdk-go/v3/server"
+)
+
+// TestSecretsAPIMock tests that the mock records calls and returns what its
+// functions return
+func TestSecretsAPIMock(t *testing.T) {
+	mock := &SecretsAPIMock{
+		SecretFunc: func(id int) (*server.Secret, error) {
+			return &server.Secret{ID: id, Name: "mocked"}, nil
+		},
+	}
+	var api server.SecretsAPI = mock
+
+	secret, err := api.Secret(42)
+	if err != nil || secret.Name != "mocked" {
+		t.Errorf("expected the mocked secret, found %+v (%v)", secret, err)
+	}
+	if calls := mock.SecretCalls(); len(calls) != 1 || calls[0].ID != 42 {
+		t.Errorf("expected one call with id 42, found %+v", calls)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a call to an unset function to panic")
+		}
+	}()
+	api.DeleteSecret(42)
+}
+
+// TestNewSecretsAPI tests that the mock of a client for a seeded fake passes
+// calls to the client and records them
+func TestNewSecretsAPI(t *testing.T) {
+	template := PasswordTemplate()
+	template.ID = 6001
+	fake, _, api, err := NewSecretsAPI(Fixtures{
+		Templates: []server.SecretTemplate{template},
+		Secrets: []server.Secret{{ID: 42, Name: "DB", SecretTemplateID: template.ID, FolderID: -1,
+			Fields: []server.SecretField{{Slug: "username", ItemValue: "app"}, {Slug: "password", ItemValue: "s3cret"}}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fake.Close()
+
+	if err = api.UpdateField(42, "password", "changed"); err != nil {
+		t.Fatal(err)
+	}
+	secret, err := api.Secret(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if password, _ := secret.Field("password"); password != "changed" {
+		t.Errorf("expected the updated password, found %q", password)
+	}
+	if len(api.UpdateFieldCalls()) != 1 || len(api.SecretCalls()) != 1 {
+		t.Errorf("expected the calls to be recorded, found %+v and %+v", api.UpdateFieldCalls(), api.SecretCalls())
+	}
+}
@@ -0,0 +1,1096 @@
+// Package tsstest provides an in-memory fake of Delinea Secret Server for
+// unit-testing code built on the server package without a live tenant.
+//
+// The fake implements the token endpoint and the health check and, for secrets,
+// create, read, update and delete, search, folder listing, lookup by path, file
+// fields, expiration, restore and erasure, which is approved at once, remote
+// password changes and heartbeats, whose outcome SetRemote controls, password
+// history, the audit trail and permissions. It also implements secret
+// templates, password generation, password requirements, which
+// SetPasswordRequirement sets, folder permissions, secret policies, which
+// AddSecretPolicy adds, and the administration of users, groups and roles,
+// which AddRole and AddApplicationAccount add. A test seeds the fake and
+// connects to it like so:
+//
+//	fake := tsstest.NewServer()
+//	defer fake.Close()
+//	template := fake.AddTemplate(tsstest.PasswordTemplate())
+//	fake.AddSecret(server.Secret{Name: "DB", SecretTemplateID: template.ID, FolderID: -1,
+//		Fields: []server.SecretField{{Slug: "password", ItemValue: "s3cret"}}})
+//
+//	tss, err := server.New(fake.Configuration())
+package tsstest
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DelineaXPM/tss-sdk-go/v3/server"
+)
+
+// The credentials accepted by the token endpoint of the fake
+const (
+	Username = "tsstest"
+	Password = "tsstest-password"
+)
+
+// fileNotValidForDisplay replaces the contents of file fields in secrets
+// returned by the fake, as Secret Server does
+const fileNotValidForDisplay = "*** Not Valid For Display ***"
+
+// Fixtures are the templates, folders and secrets with which a fake is seeded
+type Fixtures struct {
+	Templates []server.SecretTemplate
+	// Folders maps folder ids to folder paths, such as "/Prod/Databases"
+	Folders map[int]string
+	Secrets []server.Secret
+}
+
+// Server is a fake Secret Server listening on a local address
+type Server struct {
+	*httptest.Server
+
+	mutex             sync.Mutex
+	templates         map[int]*server.SecretTemplate
+	folders           map[int]string
+	secrets           map[int]*server.Secret
+	remotes           map[int]*remoteState
+	trails            map[int][]secretAudit
+	secretPermissions map[int]*server.SecretPermission
+	folderPermissions map[int]*server.FolderPermission
+	users             map[int]*userRecord
+	userRoles         map[int]map[int]bool
+	groups            map[int]*server.Group
+	groupMembers      map[int]map[int]bool
+	roles             map[int]*server.Role
+	secretPolicies    map[int]*server.SecretPolicy
+	requirements      map[int]server.PasswordRequirement
+	tokens            map[string]bool
+	requests          []string
+	nextID            int
+	clockSkew         time.Duration
+}
+
+// NewServer starts and returns a fake Secret Server with no templates or
+// secrets. The caller should Close it when finished.
+func NewServer() *Server {
+	f := &Server{
+		templates:         make(map[int]*server.SecretTemplate),
+		folders:           make(map[int]string),
+		secrets:           make(map[int]*server.Secret),
+		remotes:           make(map[int]*remoteState),
+		trails:            make(map[int][]secretAudit),
+		secretPermissions: make(map[int]*server.SecretPermission),
+		folderPermissions: make(map[int]*server.FolderPermission),
+		users:             make(map[int]*userRecord),
+		userRoles:         make(map[int]map[int]bool),
+		groups:            make(map[int]*server.Group),
+		groupMembers:      make(map[int]map[int]bool),
+		roles:             make(map[int]*server.Role),
+		secretPolicies:    make(map[int]*server.SecretPolicy),
+		requirements:      make(map[int]server.PasswordRequirement),
+		tokens:            make(map[string]bool),
+		nextID:            1,
+	}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serveHTTP))
+	// the client caches tokens in the environment by server URL, and an
+	// earlier fake may have had the same address
+	os.Unsetenv("SS_AT_" + url.QueryEscape(f.URL))
+	return f
+}
+
+// NewServerWithFixtures starts and returns a fake Secret Server seeded with
+// the fixtures
+func NewServerWithFixtures(fixtures Fixtures) (*Server, error) {
+	f := NewServer()
+	if err := f.Seed(fixtures); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// LoadFixtures reads fixtures from a JSON file
+func LoadFixtures(path string) (Fixtures, error) {
+	var fixtures Fixtures
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fixtures, err
+	}
+	if err = json.Unmarshal(data, &fixtures); err != nil {
+		return fixtures, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return fixtures, nil
+}
+
+// Configuration returns a configuration for connecting to the fake
+func (f *Server) Configuration() server.Configuration {
+	return server.Configuration{
+		ServerURL:   f.URL,
+		Credentials: server.UserCredential{Username: Username, Password: Password},
+	}
+}
+
+// Seed adds the templates and folders, then the secrets, of the fixtures.
+// Templates and secrets keep their ids, if they have them.
+func (f *Server) Seed(fixtures Fixtures) error {
+	for _, template := range fixtures.Templates {
+		f.AddTemplate(template)
+	}
+	for id, path := range fixtures.Folders {
+		f.AddFolder(id, path)
+	}
+	for _, secret := range fixtures.Secrets {
+		if _, err := f.AddSecret(secret); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddTemplate adds the secret template, assigning ids to it and its fields
+// if they have none, and slugs to fields that have none. It returns the
+// template as stored.
+func (f *Server) AddTemplate(template server.SecretTemplate) server.SecretTemplate {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return *f.storeTemplate(template)
+}
+
+// storeTemplate stores the template as AddTemplate does
+func (f *Server) storeTemplate(template server.SecretTemplate) *server.SecretTemplate {
+	template.ID = f.useID(template.ID)
+	template.Active = true
+	template.Fields = append([]server.SecretTemplateField(nil), template.Fields...)
+	for i := range template.Fields {
+		f.completeField(&template.Fields[i])
+	}
+	f.templates[template.ID] = &template
+	return &template
+}
+
+// completeField assigns an id to the template field, if it has none, and
+// defaults its slug and display name from its name
+func (f *Server) completeField(field *server.SecretTemplateField) {
+	field.SecretTemplateFieldID = f.useID(field.SecretTemplateFieldID)
+	if field.FieldSlugName == "" {
+		field.FieldSlugName = strings.ToLower(strings.Replace(field.Name, " ", "-", -1))
+	}
+	if field.DisplayName == "" {
+		field.DisplayName = field.Name
+	}
+	field.IsActive = true
+}
+
+// AddFolder adds a folder, so that secrets in it can be found by path
+func (f *Server) AddFolder(id int, path string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.folders[id] = normalizePath(path)
+}
+
+// AddSecret adds the secret, whose template must already have been added.
+// Fields may be identified by slug or field id, and the contents of file
+// fields are given as their values. It returns the secret as stored.
+func (f *Server) AddSecret(secret server.Secret) (server.Secret, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	stored, err := f.newSecret(secret)
+	if err != nil {
+		return server.Secret{}, err
+	}
+	for i := range stored.Fields {
+		field := &stored.Fields[i]
+		if field.IsFile && field.ItemValue != "" {
+			f.attach(field, field.Filename, field.ItemValue)
+		}
+	}
+	if secret.ID != 0 {
+		delete(f.secrets, stored.ID)
+		stored.ID = f.useID(secret.ID)
+		f.secrets[stored.ID] = stored
+	}
+	return copySecret(stored), nil
+}
+
+// Secret returns the stored state of the secret with the given id, including
+// deleted secrets and the contents of file fields, and whether there is one
+func (f *Server) Secret(id int) (server.Secret, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	secret, found := f.secrets[id]
+	if !found {
+		return server.Secret{}, false
+	}
+	return copySecret(secret), true
+}
+
+// Requests returns the method and path of every request the fake has
+// received, such as "GET /api/v1/secrets/1"
+func (f *Server) Requests() []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]string(nil), f.requests...)
+}
+
+// PasswordTemplate returns a template like the Password template that ships
+// with Secret Server
+func PasswordTemplate() server.SecretTemplate {
+	return server.SecretTemplate{
+		Name: "Password",
+		Fields: []server.SecretTemplateField{
+			{Name: "Resource", FieldSlugName: "resource"},
+			{Name: "Username", FieldSlugName: "username", IsRequired: true},
+			{Name: "Password", FieldSlugName: "password", IsPassword: true, IsRequired: true},
+			{Name: "Notes", FieldSlugName: "notes", IsNotes: true},
+		},
+	}
+}
+
+// SshKeyTemplate returns a template like the Unix Account (SSH Key Rotation)
+// template that ships with Secret Server
+func SshKeyTemplate() server.SecretTemplate {
+	return server.SecretTemplate{
+		Name: "Unix Account (SSH Key Rotation)",
+		Fields: []server.SecretTemplateField{
+			{Name: "Machine", FieldSlugName: "machine"},
+			{Name: "Username", FieldSlugName: "username"},
+			{Name: "Password", FieldSlugName: "password", IsPassword: true},
+			{Name: "Private Key", FieldSlugName: "private-key", IsFile: true},
+			{Name: "Public Key", FieldSlugName: "public-key", IsFile: true},
+			{Name: "Private Key Passphrase", FieldSlugName: "private-key-passphrase", IsPassword: true},
+		},
+	}
+}
+
+func (f *Server) newID() int {
+	id := f.nextID
+	f.nextID++
+	return id
+}
+
+// useID returns the given id, making sure that newID never returns it, or a
+// new id if it is zero
+func (f *Server) useID(id int) int {
+	if id == 0 {
+		return f.newID()
+	}
+	if id >= f.nextID {
+		f.nextID = id + 1
+	}
+	return id
+}
+
+// newSecret stores a new secret built from the given one, with its fields
+// completed from its template
+func (f *Server) newSecret(secret server.Secret) (*server.Secret, error) {
+	template, found := f.templates[secret.SecretTemplateID]
+	if !found {
+		return nil, fmt.Errorf("there is no secret template with id %d", secret.SecretTemplateID)
+	}
+	if secret.Name == "" {
+		return nil, fmt.Errorf("the secret name is required")
+	}
+
+	stored := copySecret(&secret)
+	stored.ID = f.newID()
+	stored.Active = true
+	stored.SshKeyArgs = nil
+	stored.Fields = make([]server.SecretField, len(template.Fields))
+	for i, templateField := range template.Fields {
+		stored.Fields[i] = server.SecretField{
+			ItemID:           f.newID(),
+			FieldID:          templateField.SecretTemplateFieldID,
+			FieldName:        templateField.Name,
+			Slug:             templateField.FieldSlugName,
+			FieldDescription: templateField.Description,
+			IsFile:           templateField.IsFile,
+			IsNotes:          templateField.IsNotes,
+			IsPassword:       templateField.IsPassword,
+		}
+	}
+	for _, field := range secret.Fields {
+		index := fieldIndex(&stored, field.Slug, field.FieldID)
+		if index < 0 {
+			return nil, fmt.Errorf("the secret template '%s' has no field '%s'", template.Name, field.Slug)
+		}
+		stored.Fields[index].ItemValue = field.ItemValue
+		stored.Fields[index].Filename = field.Filename
+	}
+	if secret.SshKeyArgs != nil && secret.SshKeyArgs.GenerateSshKeys {
+		if err := f.generateSshKeys(&stored, secret.SshKeyArgs.GeneratePassphrase); err != nil {
+			return nil, err
+		}
+	}
+	f.secrets[stored.ID] = &stored
+	return &stored, nil
+}
+
+// generateSshKeys fills the key file fields of the secret with a fake key pair
+func (f *Server) generateSshKeys(secret *server.Secret, generatePassphrase bool) error {
+	private, public := fieldIndex(secret, "private-key", 0), fieldIndex(secret, "public-key", 0)
+	if private < 0 || public < 0 {
+		return fmt.Errorf("the secret template does not support SSH key generation")
+	}
+	keyPair := sshKeyPair(server.SshKeyFormatOpenSSH, generatePassphrase)
+	f.attach(&secret.Fields[private], "id_rsa", keyPair.PrivateKey)
+	f.attach(&secret.Fields[public], "id_rsa.pub", keyPair.PublicKey)
+	if passphrase := fieldIndex(secret, "private-key-passphrase", 0); generatePassphrase && passphrase >= 0 {
+		secret.Fields[passphrase].ItemValue = keyPair.Passphrase
+	}
+	return nil
+}
+
+// sshKeyPair returns a fake SSH key pair whose private key is in the format
+func sshKeyPair(format string, generatePassphrase bool) server.SshKeyPair {
+	header := "OPENSSH PRIVATE KEY"
+	if format == server.SshKeyFormatPEM {
+		header = "RSA PRIVATE KEY"
+	}
+	keyPair := server.SshKeyPair{
+		PrivateKey: "-----BEGIN " + header + "-----\n" + randomString(64) + "\n-----END " + header + "-----\n",
+		PublicKey:  "ssh-rsa " + randomString(64) + " tsstest\n",
+	}
+	if generatePassphrase {
+		keyPair.Passphrase = randomString(20)
+	}
+	return keyPair
+}
+
+// newSshKeyPair generates a key pair that is not stored in a secret
+func (f *Server) newSshKeyPair(r *http.Request) (interface{}, error) {
+	var args struct {
+		SshKeyFormat       string
+		SshKeySize         int
+		GeneratePassphrase bool
+	}
+	if err := decode(r, &args); err != nil {
+		return nil, err
+	}
+	switch args.SshKeyFormat {
+	case "", server.SshKeyFormatOpenSSH, server.SshKeyFormatPEM:
+	default:
+		return nil, errorf(http.StatusBadRequest, "'%s' is not a valid SSH key format", args.SshKeyFormat)
+	}
+	switch args.SshKeySize {
+	case 0, 1024, 2048, 4096:
+	default:
+		return nil, errorf(http.StatusBadRequest, "'%d' is not a valid SSH key size", args.SshKeySize)
+	}
+	return sshKeyPair(args.SshKeyFormat, args.GeneratePassphrase), nil
+}
+
+// attach stores the contents as the file attachment of the field
+func (f *Server) attach(field *server.SecretField, filename, contents string) {
+	if filename == "" {
+		filename = field.Slug + ".txt"
+	}
+	field.ItemValue = contents
+	field.Filename = filename
+	field.FileAttachmentID = f.newID()
+}
+
+// fieldIndex returns the index of the field with the given slug or field id,
+// or -1
+func fieldIndex(secret *server.Secret, slug string, fieldID int) int {
+	for i, field := range secret.Fields {
+		if (slug != "" && (field.Slug == slug || field.FieldName == slug)) || (slug == "" && fieldID != 0 && field.FieldID == fieldID) {
+			return i
+		}
+	}
+	return -1
+}
+
+func copySecret(secret *server.Secret) server.Secret {
+	copied := *secret
+	copied.Fields = append([]server.SecretField(nil), secret.Fields...)
+	return copied
+}
+
+// forDisplay returns the secret as the API returns it, with the contents of
+// file fields replaced
+func forDisplay(secret *server.Secret) server.Secret {
+	displayed := copySecret(secret)
+	for i, field := range displayed.Fields {
+		if field.IsFile && field.FileAttachmentID != 0 {
+			displayed.Fields[i].ItemValue = fileNotValidForDisplay
+		}
+	}
+	return displayed
+}
+
+// normalizePath converts a folder path or secret path to the form
+// "/Folder/Name", as Secret Server accepts either slash
+func normalizePath(path string) string {
+	path = strings.Trim(strings.Replace(path, `\`, "/", -1), "/")
+	if path == "" {
+		return ""
+	}
+	return "/" + path
+}
+
+const passwordCharacters = "ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz23456789!@#$%^&*"
+
+func randomString(length int) string {
+	characters := make([]byte, length)
+	for i := range characters {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(passwordCharacters))))
+		if err != nil {
+			panic(err)
+		}
+		characters[i] = passwordCharacters[n.Int64()]
+	}
+	return string(characters)
+}
+
+// httpError is an error response
+type httpError struct {
+	status  int
+	message string
+}
+
+func (e httpError) Error() string {
+	return e.message
+}
+
+func errorf(status int, format string, args ...interface{}) error {
+	return httpError{status, fmt.Sprintf(format, args...)}
+}
+
+func (f *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+
+	response, err := f.route(r)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if e, ok := err.(httpError); ok {
+			status = e.status
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"message": err.Error()})
+		return
+	}
+	if raw, ok := response.([]byte); ok {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(raw)
+		return
+	}
+	// written without HTML escaping or a trailing newline, as the client
+	// takes generated passwords from between the quotes of the raw body
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(response); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(bytes.TrimSuffix(buffer.Bytes(), []byte("\n")))
+}
+
+// route handles the request and returns the response, which is written as
+// JSON unless it is a []byte
+func (f *Server) route(r *http.Request) (interface{}, error) {
+	switch {
+	case r.URL.Path == "/api/v1/healthcheck" && r.Method == "GET":
+		return map[string]bool{"healthy": true, "databaseHealthy": true, "serviceBusHealthy": true, "storageAccountHealthy": true}, nil
+	case r.URL.Path == "/oauth2/token" && r.Method == "POST":
+		return f.token(r)
+	case !strings.HasPrefix(r.URL.Path, "/api/v1/"):
+		return nil, errorf(http.StatusNotFound, "no such endpoint: %s", r.URL.Path)
+	}
+
+	if !f.tokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")] {
+		return nil, errorf(http.StatusUnauthorized, "Authentication failed or was not provided.")
+	}
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/"), "/"), "/")
+
+	switch parts[0] {
+	case "secrets":
+		return f.routeSecrets(r, parts[1:])
+	case "secret-templates":
+		return f.routeTemplates(r, parts[1:])
+	case "secret-permissions":
+		return f.routeSecretPermissions(r, parts[1:])
+	case "folder-permissions":
+		return f.routeFolderPermissions(r, parts[1:])
+	case "users":
+		return f.routeUsers(r, parts[1:])
+	case "groups":
+		return f.routeGroups(r, parts[1:])
+	case "roles":
+		return f.routeRoles(r, parts[1:])
+	case "secret-policy":
+		return f.routeSecretPolicies(r, parts[1:])
+	case "secret-erase-requests":
+		if len(parts) == 1 && r.Method == "POST" {
+			return f.eraseSecrets(r)
+		}
+	}
+	return nil, errorf(http.StatusNotFound, "no such endpoint: %s", r.URL.Path)
+}
+
+func (f *Server) token(r *http.Request) (interface{}, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, errorf(http.StatusBadRequest, "%s", err)
+	}
+	if r.PostForm.Get("grant_type") != "password" || r.PostForm.Get("username") != Username || r.PostForm.Get("password") != Password {
+		return nil, errorf(http.StatusBadRequest, "invalid_grant")
+	}
+	token := "tsstest-" + randomString(16)
+	f.tokens[token] = true
+	return map[string]interface{}{"access_token": token, "token_type": "bearer", "expires_in": 1200}, nil
+}
+
+func (f *Server) routeSecrets(r *http.Request, parts []string) (interface{}, error) {
+	query := r.URL.Query()
+	switch {
+	case len(parts) == 0 && r.Method == "GET":
+		return f.search(query), nil
+	case len(parts) == 0 && r.Method == "POST":
+		var secret server.Secret
+		if err := decode(r, &secret); err != nil {
+			return nil, err
+		}
+		created, err := f.newSecret(secret)
+		if err != nil {
+			return nil, errorf(http.StatusBadRequest, "%s", err)
+		}
+		f.audit(r, created.ID, "CREATE")
+		return forDisplay(created), nil
+	case len(parts) == 1 && parts[0] == "generate-ssh-keys" && r.Method == "POST":
+		return f.newSshKeyPair(r)
+	case len(parts) == 1 && parts[0] == "0" && r.Method == "GET" && query.Get("secretPath") != "":
+		secret, err := f.secretByPath(query.Get("secretPath"), query.Get("includeInactive") == "true")
+		if err != nil {
+			return nil, err
+		}
+		return forDisplay(secret), nil
+	}
+
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return nil, errorf(http.StatusBadRequest, "'%s' is not a secret id", parts[0])
+	}
+	undelete := len(parts) == 2 && parts[1] == "undelete"
+	secret, found := f.secrets[id]
+	if !found || (!secret.Active && query.Get("includeInactive") != "true" && !undelete) {
+		return nil, errorf(http.StatusNotFound, "Access Denied")
+	}
+
+	response, err := f.routeSecret(r, secret, parts[1:])
+	if err == nil {
+		f.audit(r, id, auditAction(r.Method, parts[1:]))
+	}
+	return response, err
+}
+
+// routeSecret handles a request for the secret, whose path beneath it is
+// given as parts
+func (f *Server) routeSecret(r *http.Request, secret *server.Secret, parts []string) (interface{}, error) {
+	undelete := len(parts) == 1 && parts[0] == "undelete"
+	switch {
+	case len(parts) == 0 && r.Method == "GET":
+		return forDisplay(secret), nil
+	case len(parts) == 0 && r.Method == "PUT":
+		var update server.Secret
+		if err := decode(r, &update); err != nil {
+			return nil, err
+		}
+		return f.updateSecret(secret, update)
+	case len(parts) == 0 && r.Method == "DELETE":
+		secret.Active = false
+		return map[string]interface{}{"id": secret.ID, "objectType": "Secret"}, nil
+	case undelete && r.Method == "PUT":
+		secret.Active = true
+		return forDisplay(secret), nil
+	case len(parts) == 1 && parts[0] == "general" && r.Method == "PATCH":
+		return f.patchGeneral(r, secret)
+	case len(parts) == 1 && parts[0] == "expiration" && r.Method == "PATCH":
+		return f.patchExpiration(r, secret)
+	case len(parts) == 2 && parts[0] == "fields":
+		return f.field(r, secret, parts[1])
+	case len(parts) == 1 && parts[0] == "summary" && r.Method == "GET":
+		return f.summary(secret)
+	case len(parts) == 1 && parts[0] == "change-password" && r.Method == "POST":
+		return f.changePassword(r, secret)
+	case len(parts) == 1 && parts[0] == "heartbeat" && r.Method == "POST":
+		return f.heartbeat(secret)
+	case len(parts) == 1 && parts[0] == "password-history" && r.Method == "GET":
+		return f.passwordHistory(secret)
+	case len(parts) == 1 && parts[0] == "audits" && r.Method == "GET":
+		return f.audits(r, secret)
+	}
+	return nil, errorf(http.StatusNotFound, "no such endpoint: %s %s", r.Method, r.URL.Path)
+}
+
+func decode(r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return errorf(http.StatusBadRequest, "invalid request body: %s", err)
+	}
+	return nil
+}
+
+// search implements the search parameters used by server.Secrets and the
+// folder filter used by server.FolderSecrets, and returns the requested page.
+// Without a search field, the text is searched for in the extended fields
+// given, as Secret Server does, and not in the names of secrets.
+func (f *Server) search(query url.Values) interface{} {
+	get := func(name string) string {
+		if values := query[name]; len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+	text := get("paging.filter.searchText")
+	field := get("paging.filter.searchField")
+	extendedFields := query["paging.filter.extendedFields"]
+	exact := get("paging.filter.isExactMatch") == "true"
+	includeInactive := get("paging.filter.includeInactive") == "true"
+	folderID, filterFolder := -1, get("filter.folderId") != ""
+	if filterFolder {
+		folderID, _ = strconv.Atoi(get("filter.folderId"))
+	}
+	includeSubfolders := get("filter.includeSubFolders") == "true"
+
+	matches := func(value string) bool {
+		if exact {
+			return value == text
+		}
+		return strings.Contains(strings.ToLower(value), strings.ToLower(text))
+	}
+	searched := func(secretField server.SecretField) bool {
+		if field != "" {
+			return secretField.Slug == field
+		}
+		for _, name := range extendedFields {
+			if strings.EqualFold(secretField.FieldName, name) || strings.EqualFold(secretField.Slug, name) {
+				return true
+			}
+		}
+		return false
+	}
+
+	records := make([]searchRecord, 0)
+	ids := make([]int, 0, len(f.secrets))
+	for id := range f.secrets {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		secret := f.secrets[id]
+		if !secret.Active && !includeInactive {
+			continue
+		}
+		if filterFolder && !f.inFolder(secret.FolderID, folderID, includeSubfolders) {
+			continue
+		}
+		found := text == "" && field == ""
+		for _, secretField := range secret.Fields {
+			if secretField.IsFile || secretField.IsPassword {
+				continue
+			}
+			if searched(secretField) && matches(secretField.ItemValue) {
+				found = true
+			}
+		}
+		if found {
+			record := searchRecord{
+				ID: secret.ID, Name: secret.Name, FolderID: secret.FolderID,
+				SecretTemplateID: secret.SecretTemplateID, Active: secret.Active,
+			}
+			if expiration, expires := secret.Expiration(); expires {
+				days := int(time.Until(expiration).Hours() / 24)
+				record.DaysUntilExpiration = &days
+			}
+			records = append(records, record)
+		}
+	}
+	sortRecords(records, get("sortBy[0].name"), get("sortBy[0].direction"))
+
+	skip, end, response := pageOf(query, len(records))
+	response["SearchText"] = text
+	response["Records"] = records[skip:end]
+	return response
+}
+
+// searchRecord is a secret as search results summarize it
+type searchRecord struct {
+	ID, FolderID, SecretTemplateID int
+	Name                           string
+	Active                         bool
+	DaysUntilExpiration            *int
+}
+
+// sortRecords sorts search results by name or by days until expiration, with
+// secrets that do not expire first, as SQL Server sorts nulls. They are
+// otherwise left in id order.
+func sortRecords(records []searchRecord, by, direction string) {
+	var less func(a, b searchRecord) bool
+	switch strings.ToLower(by) {
+	case "name":
+		less = func(a, b searchRecord) bool { return strings.ToLower(a.Name) < strings.ToLower(b.Name) }
+	case "daysuntilexpiration":
+		less = func(a, b searchRecord) bool {
+			if a.DaysUntilExpiration == nil || b.DaysUntilExpiration == nil {
+				return a.DaysUntilExpiration == nil && b.DaysUntilExpiration != nil
+			}
+			return *a.DaysUntilExpiration < *b.DaysUntilExpiration
+		}
+	default:
+		return
+	}
+	descending := strings.EqualFold(direction, "desc")
+	sort.SliceStable(records, func(i, j int) bool {
+		if descending {
+			return less(records[j], records[i])
+		}
+		return less(records[i], records[j])
+	})
+}
+
+// inFolder reports whether the folder with id is the folder with folderID or,
+// if includeSubfolders is set, one of its subfolders
+func (f *Server) inFolder(id, folderID int, includeSubfolders bool) bool {
+	if id == folderID {
+		return true
+	}
+	path, found := f.folders[folderID]
+	return includeSubfolders && found && strings.HasPrefix(strings.ToLower(f.folders[id]), strings.ToLower(path)+"/")
+}
+
+func (f *Server) secretByPath(path string, includeInactive bool) (*server.Secret, error) {
+	path = normalizePath(path)
+	for _, secret := range f.secrets {
+		if !secret.Active && !includeInactive {
+			continue
+		}
+		if strings.EqualFold(f.folders[secret.FolderID]+"/"+secret.Name, path) {
+			return secret, nil
+		}
+	}
+	return nil, errorf(http.StatusNotFound, "Access Denied")
+}
+
+// updateSecret replaces the settings and the given fields of the secret;
+// fields that are not given, such as file fields, keep their values
+func (f *Server) updateSecret(secret *server.Secret, update server.Secret) (interface{}, error) {
+	for _, field := range update.Fields {
+		index := fieldIndex(secret, field.Slug, field.FieldID)
+		if index < 0 {
+			return nil, errorf(http.StatusBadRequest, "the secret has no field '%s'", field.Slug)
+		}
+		switch {
+		case secret.Fields[index].IsPassword:
+			f.setPassword(secret, index, field.ItemValue)
+		case !secret.Fields[index].IsFile:
+			secret.Fields[index].ItemValue = field.ItemValue
+		}
+	}
+	fields := secret.Fields
+	active := secret.Active
+	update.ID, update.SecretTemplateID, update.SshKeyArgs = secret.ID, secret.SecretTemplateID, nil
+	*secret = update
+	secret.Fields, secret.Active = fields, active
+	return forDisplay(secret), nil
+}
+
+// patchGeneral applies the field changes, name, folder and secret policy of a
+// PATCH of the general section of the secret
+func (f *Server) patchGeneral(r *http.Request, secret *server.Secret) (interface{}, error) {
+	type dirtyValue struct {
+		Dirty bool
+		Value interface{}
+	}
+	var patch struct {
+		Data struct {
+			SecretFields []struct {
+				Slug  string
+				Dirty bool
+				Value *string
+			}
+			Name, Folder, EnableInheritPermissions, SecretPolicy *dirtyValue
+		}
+	}
+	if err := decode(r, &patch); err != nil {
+		return nil, err
+	}
+	// the patch is checked before any of it is applied
+	for _, mod := range patch.Data.SecretFields {
+		if fieldIndex(secret, mod.Slug, 0) < 0 {
+			return nil, errorf(http.StatusBadRequest, "the secret has no field '%s'", mod.Slug)
+		}
+	}
+	folderID := secret.FolderID
+	if folder := patch.Data.Folder; folder != nil && folder.Dirty {
+		id, ok := folder.Value.(float64)
+		if _, found := f.folders[int(id)]; !ok || (!found && id != -1) {
+			return nil, errorf(http.StatusBadRequest, "there is no folder with id %v", folder.Value)
+		}
+		folderID = int(id)
+	}
+	policyID := secret.SecretPolicyID
+	if policy := patch.Data.SecretPolicy; policy != nil && policy.Dirty {
+		id, ok := policy.Value.(float64)
+		if _, found := f.secretPolicies[int(id)]; !ok || !found {
+			return nil, errorf(http.StatusBadRequest, "there is no secret policy with id %v", policy.Value)
+		}
+		policyID = int(id)
+	}
+
+	for _, mod := range patch.Data.SecretFields {
+		if !mod.Dirty {
+			continue
+		}
+		index := fieldIndex(secret, mod.Slug, 0)
+		field := &secret.Fields[index]
+		value := ""
+		if mod.Value != nil {
+			value = *mod.Value
+		}
+		if field.IsPassword {
+			f.setPassword(secret, index, value)
+		} else {
+			field.ItemValue = value
+		}
+		if field.IsFile && mod.Value == nil {
+			field.Filename, field.FileAttachmentID = "", 0
+		}
+	}
+	if name := patch.Data.Name; name != nil && name.Dirty {
+		secret.Name = fmt.Sprint(name.Value)
+	}
+	secret.FolderID, secret.SecretPolicyID = folderID, policyID
+	if inherit := patch.Data.EnableInheritPermissions; inherit != nil && inherit.Dirty {
+		secret.EnableInheritPermissions = inherit.Value == true
+	}
+	return forDisplay(secret), nil
+}
+
+// patchExpiration sets or clears the expiration date of the secret
+func (f *Server) patchExpiration(r *http.Request, secret *server.Secret) (interface{}, error) {
+	var patch struct {
+		Data struct {
+			ExpirationType, ExpirationDate struct {
+				Dirty bool
+				Value *string
+			}
+		}
+	}
+	if err := decode(r, &patch); err != nil {
+		return nil, err
+	}
+	switch expiration := patch.Data; {
+	case expiration.ExpirationType.Value != nil && *expiration.ExpirationType.Value == "None":
+		secret.ExpirationDate = ""
+	case expiration.ExpirationType.Value != nil && *expiration.ExpirationType.Value == "Date" && expiration.ExpirationDate.Value != nil:
+		if _, err := time.Parse(time.RFC3339, *expiration.ExpirationDate.Value); err != nil {
+			return nil, errorf(http.StatusBadRequest, "invalid expiration date: %s", err)
+		}
+		secret.ExpirationDate = *expiration.ExpirationDate.Value
+	default:
+		return nil, errorf(http.StatusBadRequest, "ExpirationType must be None, or Date with an ExpirationDate")
+	}
+	return forDisplay(secret), nil
+}
+
+// field reads or writes a field: file fields as raw contents or multipart
+// uploads, other fields as JSON
+func (f *Server) field(r *http.Request, secret *server.Secret, slug string) (interface{}, error) {
+	index := fieldIndex(secret, slug, 0)
+	if index < 0 {
+		return nil, errorf(http.StatusNotFound, "the secret has no field '%s'", slug)
+	}
+	field := &secret.Fields[index]
+
+	switch r.Method {
+	case "GET":
+		if field.IsFile {
+			return []byte(field.ItemValue), nil
+		}
+		return field.ItemValue, nil
+	case "PUT":
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+			if !field.IsFile {
+				return nil, errorf(http.StatusBadRequest, "the field '%s' is not a file field", slug)
+			}
+			file, header, err := r.FormFile("file")
+			if err != nil {
+				return nil, errorf(http.StatusBadRequest, "%s", err)
+			}
+			defer file.Close()
+			contents, err := ioutil.ReadAll(file)
+			if err != nil {
+				return nil, err
+			}
+			f.attach(field, header.Filename, string(contents))
+			return map[string]interface{}{}, nil
+		}
+		var input struct{ Value string }
+		if err := decode(r, &input); err != nil {
+			return nil, err
+		}
+		if field.IsPassword {
+			f.setPassword(secret, index, input.Value)
+		} else {
+			field.ItemValue = input.Value
+		}
+		return input.Value, nil
+	}
+	return nil, errorf(http.StatusMethodNotAllowed, "%s is not supported for fields", r.Method)
+}
+
+func (f *Server) routeTemplates(r *http.Request, parts []string) (interface{}, error) {
+	switch {
+	case len(parts) == 0 && r.Method == "GET":
+		query := r.URL.Query()
+		text := strings.ToLower(query.Get("filter.searchText"))
+		includeInactive := query.Get("filter.includeInactive") == "true"
+		templates := make([]server.SecretTemplate, 0, len(f.templates))
+		for _, template := range f.templates {
+			if (template.Active || includeInactive) && strings.Contains(strings.ToLower(template.Name), text) {
+				templates = append(templates, server.SecretTemplate{ID: template.ID, Name: template.Name, Active: template.Active})
+			}
+		}
+		sort.Slice(templates, func(i, j int) bool { return templates[i].ID < templates[j].ID })
+		skip, end, response := pageOf(query, len(templates))
+		response["Records"] = templates[skip:end]
+		return response, nil
+	case len(parts) == 0 && r.Method == "POST":
+		var template server.SecretTemplate
+		if err := decode(r, &template); err != nil {
+			return nil, err
+		}
+		if template.Name == "" {
+			return nil, errorf(http.StatusBadRequest, "Name is required")
+		}
+		for _, existing := range f.templates {
+			if strings.EqualFold(existing.Name, template.Name) {
+				return nil, errorf(http.StatusBadRequest, "the secret template name '%s' is already in use", template.Name)
+			}
+		}
+		for _, field := range template.Fields {
+			if field.Name == "" {
+				return nil, errorf(http.StatusBadRequest, "every field requires a Name")
+			}
+		}
+		template.ID = 0
+		for i := range template.Fields {
+			template.Fields[i].SecretTemplateFieldID = 0
+		}
+		return f.storeTemplate(template), nil
+	case len(parts) == 2 && parts[0] == "generate-password" && r.Method == "POST":
+		fieldID, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return nil, errorf(http.StatusBadRequest, "'%s' is not a field id", parts[1])
+		}
+		if f.isPasswordField(fieldID) {
+			return randomString(20), nil
+		}
+		return nil, errorf(http.StatusBadRequest, "there is no password field with id %d", fieldID)
+	case len(parts) == 3 && parts[0] == "fields" && parts[2] == "password-requirements" && r.Method == "GET":
+		return f.passwordRequirement(parts[1])
+	}
+
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return nil, errorf(http.StatusBadRequest, "'%s' is not a secret template id", parts[0])
+	}
+	template, found := f.templates[id]
+	if !found {
+		return nil, errorf(http.StatusNotFound, "there is no secret template with id %d", id)
+	}
+
+	switch {
+	case len(parts) == 1 && r.Method == "GET":
+		return template, nil
+	case len(parts) == 2 && parts[1] == "fields" && r.Method == "POST":
+		return f.addTemplateField(r, template)
+	case len(parts) == 3 && parts[1] == "fields" && r.Method == "PUT":
+		return f.updateTemplateField(r, template, parts[2])
+	}
+	return nil, errorf(http.StatusNotFound, "no such endpoint: %s %s", r.Method, r.URL.Path)
+}
+
+// addTemplateField adds a field to the template
+func (f *Server) addTemplateField(r *http.Request, template *server.SecretTemplate) (interface{}, error) {
+	var field server.SecretTemplateField
+	if err := decode(r, &field); err != nil {
+		return nil, err
+	}
+	if field.Name == "" {
+		return nil, errorf(http.StatusBadRequest, "Name is required")
+	}
+	field.SecretTemplateFieldID = 0
+	f.completeField(&field)
+	if _, found := template.GetField(field.FieldSlugName); found {
+		return nil, errorf(http.StatusBadRequest, "the secret template already has a field '%s'", field.FieldSlugName)
+	}
+	template.Fields = append(template.Fields, field)
+	return field, nil
+}
+
+// updateTemplateField replaces the field of the template with the given id
+func (f *Server) updateTemplateField(r *http.Request, template *server.SecretTemplate, fieldID string) (interface{}, error) {
+	id, err := strconv.Atoi(fieldID)
+	if err != nil {
+		return nil, errorf(http.StatusBadRequest, "'%s' is not a field id", fieldID)
+	}
+	var field server.SecretTemplateField
+	if err := decode(r, &field); err != nil {
+		return nil, err
+	}
+	for i, existing := range template.Fields {
+		if existing.SecretTemplateFieldID == id {
+			if field.Name == "" || field.FieldSlugName == "" {
+				return nil, errorf(http.StatusBadRequest, "Name and FieldSlugName are required")
+			}
+			field.SecretTemplateFieldID = id
+			template.Fields[i] = field
+			return field, nil
+		}
+	}
+	return nil, errorf(http.StatusNotFound, "the secret template has no field with id %d", id)
+}
+
+// eraseSecrets erases deleted secrets at once, as if the erase request had
+// been approved
+func (f *Server) eraseSecrets(r *http.Request) (interface{}, error) {
+	var input struct {
+		SecretIDs    []int
+		RequestNotes string
+	}
+	if err := decode(r, &input); err != nil {
+		return nil, err
+	}
+	if len(input.SecretIDs) == 0 {
+		return nil, errorf(http.StatusBadRequest, "SecretIds is required")
+	}
+	for _, id := range input.SecretIDs {
+		if secret, found := f.secrets[id]; !found || secret.Active {
+			return nil, errorf(http.StatusBadRequest, "the secret with id %d is not a deleted secret", id)
+		}
+	}
+	for _, id := range input.SecretIDs {
+		delete(f.secrets, id)
+	}
+	return map[string]interface{}{"SecretEraseRequestId": f.newID()}, nil
+}
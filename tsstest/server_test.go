@@ -0,0 +1,184 @@
+package tsstest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/DelineaXPM/tss-sdk-go/v3/server"
+)
+
+func newClient(t *testing.T, fake *Server) *server.Server {
+	tss, err := server.New(fake.Configuration())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tss
+}
+
+// TestSecretLifecycle tests the client against the fake through creation,
+// lookup, search, update and deletion of a secret
+func TestSecretLifecycle(t *testing.T) {
+	fake := NewServer()
+	defer fake.Close()
+	template := fake.AddTemplate(PasswordTemplate())
+	fake.AddFolder(7, `\Prod\Databases`)
+	tss := newClient(t, fake)
+
+	password, err := tss.GeneratePassword("password", &template)
+	if err != nil || len(password) != 20 {
+		t.Fatalf("expected a generated password, found %q (%v)", password, err)
+	}
+
+	created, err := tss.CreateSecret(server.Secret{
+		Name: "DB", SiteID: 1, FolderID: 7, SecretTemplateID: template.ID,
+		Fields: []server.SecretField{{Slug: "username", ItemValue: "app"}, {Slug: "password", ItemValue: password}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := created.Field("password"); value != password {
+		t.Errorf("expected the created secret to have the generated password, found %q", value)
+	}
+
+	byPath, err := tss.SecretByPath("/Prod/Databases/DB")
+	if err != nil || byPath.ID != created.ID {
+		t.Errorf("expected to find the secret by path, found %+v (%v)", byPath, err)
+	}
+
+	found, err := tss.Secrets("app", "username")
+	if err != nil || len(found) != 1 || found[0].ID != created.ID {
+		t.Errorf("expected to find the secret by username, found %+v (%v)", found, err)
+	}
+	if found, _ := tss.Secrets("ap", "username"); len(found) != 0 {
+		t.Errorf("expected a field search to match exactly, found %d secrets", len(found))
+	}
+
+	for i, field := range created.Fields {
+		if field.Slug == "username" {
+			created.Fields[i].ItemValue = "other"
+		}
+	}
+	if _, err = tss.UpdateSecret(*created); err != nil {
+		t.Fatal(err)
+	}
+	if err = tss.UpdateField(created.ID, "notes", "rotated"); err != nil {
+		t.Fatal(err)
+	}
+	stored, _ := fake.Secret(created.ID)
+	if username, _ := stored.Field("username"); username != "other" {
+		t.Errorf("expected the updated username, found %q", username)
+	}
+	if notes, _ := stored.Field("notes"); notes != "rotated" {
+		t.Errorf("expected the updated notes, found %q", notes)
+	}
+
+	if err = tss.DeleteSecret(created.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tss.Secret(created.ID); err == nil {
+		t.Error("expected a deleted secret not to be found")
+	}
+	if _, err = tss.SecretWithOptions(created.ID, server.LookupOptions{IncludeInactive: true}); err != nil {
+		t.Errorf("expected a deleted secret to be found when including inactive secrets: %s", err)
+	}
+}
+
+// TestFileFields tests uploading, downloading and generating file fields
+func TestFileFields(t *testing.T) {
+	fake := NewServer()
+	defer fake.Close()
+	template := fake.AddTemplate(SshKeyTemplate())
+	tss := newClient(t, fake)
+
+	created, err := tss.CreateSecret(server.Secret{
+		Name: "host", SiteID: 1, FolderID: -1, SecretTemplateID: template.ID,
+		Fields: []server.SecretField{
+			{Slug: "machine", ItemValue: "host.example.com"},
+			{Slug: "private-key", Filename: "id_ed25519", ItemValue: "PRIVATE"},
+			{Slug: "public-key", Filename: "id_ed25519.pub", ItemValue: "PUBLIC"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key, _ := created.Field("private-key"); key != "PRIVATE" {
+		t.Errorf("expected the uploaded private key to be downloaded, found %q", key)
+	}
+
+	generated, err := tss.CreateSecret(server.Secret{
+		Name: "generated", SiteID: 1, FolderID: -1, SecretTemplateID: template.ID,
+		SshKeyArgs: &server.SshKeyArgs{GenerateSshKeys: true, GeneratePassphrase: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key, _ := generated.Field("public-key"); key == "" || key == fileNotValidForDisplay {
+		t.Errorf("expected a generated public key, found %q", key)
+	}
+	if passphrase, _ := generated.Field("private-key-passphrase"); passphrase == "" {
+		t.Error("expected a generated passphrase")
+	}
+}
+
+// TestSeed tests seeding the fake with fixtures and rejecting bad tokens
+func TestSeed(t *testing.T) {
+	fake, err := NewServerWithFixtures(Fixtures{
+		Templates: []server.SecretTemplate{func() server.SecretTemplate { t := PasswordTemplate(); t.ID = 6001; return t }()},
+		Secrets: []server.Secret{{ID: 42, Name: "seeded", SecretTemplateID: 6001,
+			Fields: []server.SecretField{{Slug: "password", ItemValue: "s3cret"}}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fake.Close()
+
+	secret, err := newClient(t, fake).Secret(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if password, _ := secret.Field("password"); password != "s3cret" {
+		t.Errorf("expected the seeded password, found %q", password)
+	}
+
+	config := fake.Configuration()
+	config.Credentials = server.UserCredential{Token: "forged"}
+	tss, err := server.New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tss.Secret(42); err == nil {
+		t.Error("expected a token not issued by the fake to be rejected")
+	}
+}
+
+// TestSearchPaging tests that the fake pages search results, and searches
+// only the extended fields when no field is given
+func TestSearchPaging(t *testing.T) {
+	fake := NewServer()
+	defer fake.Close()
+	template := fake.AddTemplate(PasswordTemplate())
+	fake.AddFolder(7, "/Prod")
+	for i := 0; i < 45; i++ {
+		if _, err := fake.AddSecret(server.Secret{Name: fmt.Sprintf("app-%d", i), SecretTemplateID: template.ID, FolderID: 7,
+			Fields: []server.SecretField{{Slug: "username", ItemValue: "svc"}}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tss := newClient(t, fake)
+
+	secrets, err := tss.FolderSecrets(7, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(secrets) != 45 || secrets[44].Name != "app-44" {
+		t.Errorf("expected every secret in the folder, over several pages, found %d", len(secrets))
+	}
+
+	// searches request a single page of 30
+	if secrets, err = tss.Secrets("svc", ""); err != nil || len(secrets) != 30 {
+		t.Errorf("expected the first page of secrets with a matching username, found %d (%v)", len(secrets), err)
+	}
+	if secrets, err = tss.Secrets("app", ""); err != nil || len(secrets) != 0 {
+		t.Errorf("expected secret names not to be searched, found %d (%v)", len(secrets), err)
+	}
+}